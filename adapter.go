@@ -1,6 +1,7 @@
 package bmssp
 
 import (
+	"context"
 	"fmt"
 	"sort"
 
@@ -91,18 +92,58 @@ func SolveGonum(g graph.WeightedDirected, sourceID, goalID int64) (float64, []in
 		return 0, nil, fmt.Errorf("goal node %d not found in graph", goalID)
 	}
 
-	solver := NewSolver(converter.Graph)
+	solver, err := TryNewSolver(converter.Graph)
+	if err != nil {
+		return 0, nil, err
+	}
 	dist, pathIndices := solver.Solve(srcIdx, goalIdx)
 
 	if pathIndices == nil {
 		return dist, nil, nil // No path found, dist is Inf
 	}
 
-	// Convert path back to Gonum IDs
-	pathIDs := make([]int64, len(pathIndices))
-	for i, idx := range pathIndices {
-		pathIDs[i] = converter.BMSSPToGonum[idx]
+	return dist, converter.ToGonumPath(pathIndices), nil
+}
+
+// ToGonumPath converts a path of BMSSP indices to Gonum node IDs.
+func (c *GonumConverter) ToGonumPath(path []int) []int64 {
+	if path == nil {
+		return nil
+	}
+	ids := make([]int64, len(path))
+	for i, idx := range path {
+		ids[i] = c.BMSSPToGonum[idx]
+	}
+	return ids
+}
+
+// DistanceMatrix computes the distances between Gonum nodes on the converted
+// graph. Row i and column j of the result correspond to origins[i] and
+// destinations[j]; paths from DistanceMatrix.Path can be converted back with
+// ToGonumPath. Workers bounds the parallelism as in NewPool.
+func (c *GonumConverter) DistanceMatrix(ctx context.Context, origins, destinations []int64, workers int, keepTrees bool) (*DistanceMatrix, error) {
+	if err := c.Graph.Validate(); err != nil {
+		return nil, err
+	}
+	originIdx, err := c.indices(origins)
+	if err != nil {
+		return nil, err
+	}
+	destinationIdx, err := c.indices(destinations)
+	if err != nil {
+		return nil, err
 	}
+	return NewPool(c.Graph, workers, Options{}).DistanceMatrix(ctx, originIdx, destinationIdx, keepTrees)
+}
 
-	return dist, pathIDs, nil
+func (c *GonumConverter) indices(ids []int64) ([]int, error) {
+	out := make([]int, len(ids))
+	for i, id := range ids {
+		idx, ok := c.GonumToBMSSP[id]
+		if !ok {
+			return nil, fmt.Errorf("node %d not found in graph", id)
+		}
+		out[i] = idx
+	}
+	return out, nil
 }
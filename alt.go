@@ -0,0 +1,294 @@
+package bmssp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"math"
+	"math/rand"
+)
+
+// LandmarkStrategy selects how NewLandmarks picks its landmarks.
+type LandmarkStrategy int
+
+const (
+	// LandmarksFarthest starts from the vertex farthest from a random start
+	// and then repeatedly adds the vertex farthest from all landmarks chosen
+	// so far. It usually gives much tighter bounds than random selection.
+	LandmarksFarthest LandmarkStrategy = iota
+	// LandmarksRandom picks landmarks uniformly at random.
+	LandmarksRandom
+)
+
+// String returns the name of the strategy.
+func (s LandmarkStrategy) String() string {
+	switch s {
+	case LandmarksFarthest:
+		return "farthest"
+	case LandmarksRandom:
+		return "random"
+	default:
+		return fmt.Sprintf("LandmarkStrategy(%d)", int(s))
+	}
+}
+
+// Landmarks holds the distance tables used by ALT (A*, landmarks and the
+// triangle inequality). From[i][v] is the distance from landmark Vertices[i] to
+// v and To[i][v] the distance from v to the landmark; unreachable entries are
+// +Inf. The tables depend only on the graph, so they can be built offline and
+// stored with WriteTo.
+type Landmarks struct {
+	Vertices []int
+	From     [][]float64
+	To       [][]float64
+}
+
+// NewLandmarks selects up to count landmarks of g with the given strategy and
+// computes their distance tables. Vertices without any edges are never chosen,
+// so fewer landmarks are returned on graphs with fewer connected vertices. seed
+// makes the selection reproducible.
+func NewLandmarks(g *Graph, count int, strategy LandmarkStrategy, seed int64) *Landmarks {
+	l, _ := NewLandmarksContext(context.Background(), g, count, strategy, seed)
+	return l
+}
+
+// NewLandmarksContext is like NewLandmarks but gives up once ctx is done and
+// returns ctx.Err().
+func NewLandmarksContext(ctx context.Context, g *Graph, count int, strategy LandmarkStrategy, seed int64) (*Landmarks, error) {
+	forward := g.CSR()
+	backward := forward.Reverse()
+	n := forward.Vertices
+	rng := rand.New(rand.NewSource(seed))
+
+	candidate := make([]bool, n)
+	for v := 0; v < n; v++ {
+		candidate[v] = forward.Degree(v) > 0 || backward.Degree(v) > 0
+	}
+
+	// The tables are single-source trees on the graph and on its reverse, so
+	// they come from the same solvers as every other query.
+	fromSolver := NewCSRSolver(forward)
+	toSolver := NewCSRSolver(backward)
+	l := &Landmarks{}
+	add := func(v int) error {
+		from, err := fromSolver.SolveAllContext(ctx, v)
+		if err != nil {
+			return err
+		}
+		to, err := toSolver.SolveAllContext(ctx, v)
+		if err != nil {
+			return err
+		}
+		l.Vertices = append(l.Vertices, v)
+		l.From = append(l.From, from.Distances)
+		l.To = append(l.To, to.Distances)
+		candidate[v] = false
+		return nil
+	}
+
+	switch strategy {
+	case LandmarksRandom:
+		for _, v := range rng.Perm(n) {
+			if len(l.Vertices) >= count {
+				break
+			}
+			if candidate[v] {
+				if err := add(v); err != nil {
+					return nil, err
+				}
+			}
+		}
+	default:
+		if count <= 0 || n == 0 {
+			break
+		}
+		start, err := fromSolver.SolveAllContext(ctx, rng.Intn(n))
+		if err != nil {
+			return nil, err
+		}
+		// nearest[v] is the distance to v from the closest landmark so far;
+		// vertices unreachable from every landmark count as farthest.
+		nearest := start.Distances
+		for len(l.Vertices) < count {
+			next := farthestCandidate(nearest, candidate)
+			if next < 0 {
+				break
+			}
+			if err := add(next); err != nil {
+				return nil, err
+			}
+			from := l.From[len(l.From)-1]
+			if len(l.Vertices) == 1 {
+				nearest = append([]float64(nil), from...)
+				continue
+			}
+			for v, d := range from {
+				if d < nearest[v] {
+					nearest[v] = d
+				}
+			}
+		}
+	}
+	return l, nil
+}
+
+// farthestCandidate returns the candidate with the largest distance, preferring
+// the lowest id on ties, or -1 if there is none.
+func farthestCandidate(dist []float64, candidate []bool) int {
+	best := -1
+	for v, d := range dist {
+		if candidate[v] && (best < 0 || d > dist[best]) {
+			best = v
+		}
+	}
+	return best
+}
+
+// Heuristic returns the ALT lower bound on the distance to goal. For each
+// landmark L the triangle inequality gives d(v, goal) >= d(L, goal) - d(L, v)
+// and d(v, goal) >= d(v, L) - d(goal, L); the heuristic is the largest of these
+// bounds and is consistent. It is +Inf where the tables prove goal unreachable.
+func (l *Landmarks) Heuristic(goal int) Heuristic {
+	fromGoal := make([]float64, len(l.Vertices))
+	toGoal := make([]float64, len(l.Vertices))
+	for i := range l.Vertices {
+		fromGoal[i] = l.From[i][goal]
+		toGoal[i] = l.To[i][goal]
+	}
+	return func(v int) float64 {
+		best := 0.0
+		for i := range fromGoal {
+			// Both differences are NaN only when both terms are infinite,
+			// in which case they carry no information and are skipped.
+			if d := fromGoal[i] - l.From[i][v]; d > best {
+				best = d
+			}
+			if d := l.To[i][v] - toGoal[i]; d > best {
+				best = d
+			}
+		}
+		return best
+	}
+}
+
+// ALT answers a point-to-point query with A* guided by the landmark tables. It
+// returns the same distance as Dijkstra as long as l was built for g.
+func ALT(g *Graph, l *Landmarks, source, goal int) (float64, []int) {
+	return AStar(g, source, goal, l.Heuristic(goal))
+}
+
+// ALTContext is like ALT but gives up once ctx is done and returns ctx.Err().
+func ALTContext(ctx context.Context, g *Graph, l *Landmarks, source, goal int) (float64, []int, error) {
+	return AStarContext(ctx, g, source, goal, l.Heuristic(goal))
+}
+
+// Landmark file layout: the 8-byte magic, then little-endian uint32 version,
+// uint32 padding, uint64 landmark count, uint64 vertex count and the
+// CRC-64/ECMA checksum of the payload. The payload holds the landmark ids as
+// int64 values followed by the From and To tables as float64 rows.
+const (
+	landmarksMagic      = "BMSSPLMK"
+	landmarksVersion    = 1
+	landmarksHeaderSize = 40
+)
+
+// WriteTo writes the landmark tables in a versioned, checksummed binary format
+// that ReadLandmarks reads back.
+func (l *Landmarks) WriteTo(w io.Writer) (int64, error) {
+	vertices := 0
+	if len(l.From) > 0 {
+		vertices = len(l.From[0])
+	}
+	hash := crc64.New(snapshotTable)
+	writePayload := func(out io.Writer) (int64, error) {
+		bw := bufio.NewWriter(out)
+		buf := make([]byte, 8)
+		var written int64
+		put := func(bits uint64) {
+			binary.LittleEndian.PutUint64(buf, bits)
+			k, _ := bw.Write(buf)
+			written += int64(k)
+		}
+		for _, v := range l.Vertices {
+			put(uint64(v))
+		}
+		for _, table := range [][][]float64{l.From, l.To} {
+			for _, row := range table {
+				for _, d := range row {
+					put(math.Float64bits(d))
+				}
+			}
+		}
+		return written, bw.Flush()
+	}
+	if _, err := writePayload(hash); err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, landmarksHeaderSize)
+	copy(header, landmarksMagic)
+	binary.LittleEndian.PutUint32(header[8:], landmarksVersion)
+	binary.LittleEndian.PutUint64(header[16:], uint64(len(l.Vertices)))
+	binary.LittleEndian.PutUint64(header[24:], uint64(vertices))
+	binary.LittleEndian.PutUint64(header[32:], hash.Sum64())
+
+	k, err := w.Write(header)
+	if err != nil {
+		return int64(k), err
+	}
+	written, err := writePayload(w)
+	return int64(k) + written, err
+}
+
+// ReadLandmarks reads landmark tables written by Landmarks.WriteTo and verifies
+// their checksum. Malformed input is reported with ErrSnapshotFormat.
+func ReadLandmarks(r io.Reader) (*Landmarks, error) {
+	header := make([]byte, landmarksHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: reading header: %v", ErrSnapshotFormat, err)
+	}
+	if string(header[:8]) != landmarksMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrSnapshotFormat)
+	}
+	if version := binary.LittleEndian.Uint32(header[8:]); version != landmarksVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrSnapshotFormat, version)
+	}
+	count := binary.LittleEndian.Uint64(header[16:])
+	vertices := binary.LittleEndian.Uint64(header[24:])
+	checksum := binary.LittleEndian.Uint64(header[32:])
+
+	limit := uint64(maxInt / 8)
+	if count >= limit || vertices >= limit || (vertices > 0 && count >= limit/(2*vertices+1)) {
+		return nil, fmt.Errorf("%w: payload too large for this platform", ErrSnapshotFormat)
+	}
+	k, n := int(count), int(vertices)
+	payload, err := readSnapshotPayload(r, 8*k*(2*n+1))
+	if err != nil {
+		return nil, err
+	}
+	if crc64.Checksum(payload, snapshotTable) != checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrSnapshotFormat)
+	}
+
+	l := &Landmarks{Vertices: decodeInts(payload[:8*k], false)}
+	for _, v := range l.Vertices {
+		if v < 0 || v >= n {
+			return nil, fmt.Errorf("%w: landmark out of range", ErrSnapshotFormat)
+		}
+	}
+	payload = payload[8*k:]
+	rows := func() [][]float64 {
+		table := make([][]float64, k)
+		for i := range table {
+			table[i] = decodeFloats(payload[:8*n], false)
+			payload = payload[8*n:]
+		}
+		return table
+	}
+	l.From = rows()
+	l.To = rows()
+	return l, nil
+}
@@ -0,0 +1,109 @@
+package bmssp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestALT(t *testing.T) {
+	rng := rand.New(rand.NewSource(161))
+	for _, strategy := range []LandmarkStrategy{LandmarksFarthest, LandmarksRandom} {
+		for iter := 0; iter < 10; iter++ {
+			n := 10 + rng.Intn(60)
+			g := makeSparseGraph(n, 3*n, rng.Int63())
+			l := NewLandmarks(g, 4, strategy, rng.Int63())
+			if len(l.Vertices) != 4 {
+				t.Fatalf("%v: expected 4 landmarks, got %v", strategy, l.Vertices)
+			}
+			for trial := 0; trial < 10; trial++ {
+				source, goal := rng.Intn(n), rng.Intn(n)
+				if err := CheckHeuristic(g, goal, l.Heuristic(goal)); err != nil {
+					t.Fatalf("%v: %v", strategy, err)
+				}
+				want, _ := Dijkstra(g, source, goal)
+				got, path := ALT(g, l, source, goal)
+				if math.IsInf(want, 1) {
+					if !math.IsInf(got, 1) || path != nil {
+						t.Fatalf("expected no path %d->%d, got %f %v", source, goal, got, path)
+					}
+					continue
+				}
+				if math.Abs(got-want) > 1e-9 {
+					t.Fatalf("%v: distance mismatch %d->%d: %f vs %f", strategy, source, goal, got, want)
+				}
+				assertValidPath(t, g, source, goal, got, path)
+			}
+		}
+	}
+}
+
+func TestLandmarks_Tables(t *testing.T) {
+	g := makeSparseGraph(30, 90, 163)
+	l := NewLandmarks(g, 3, LandmarksFarthest, 1)
+	seen := make(map[int]bool)
+	for i, landmark := range l.Vertices {
+		if seen[landmark] {
+			t.Fatalf("landmark %d chosen twice", landmark)
+		}
+		seen[landmark] = true
+		for v := 0; v < g.Vertices; v++ {
+			from, _ := Dijkstra(g, landmark, v)
+			to, _ := Dijkstra(g, v, landmark)
+			if l.From[i][v] != from && !(math.IsInf(from, 1) && math.IsInf(l.From[i][v], 1)) {
+				t.Fatalf("From[%d][%d] = %f, want %f", i, v, l.From[i][v], from)
+			}
+			if math.Abs(l.To[i][v]-to) > 1e-9 && !(math.IsInf(to, 1) && math.IsInf(l.To[i][v], 1)) {
+				t.Fatalf("To[%d][%d] = %f, want %f", i, v, l.To[i][v], to)
+			}
+		}
+	}
+}
+
+func TestLandmarks_SkipsIsolatedVertices(t *testing.T) {
+	g := NewGraph(5)
+	g.AddEdge(0, 1, 1)
+	l := NewLandmarks(g, 4, LandmarksFarthest, 1)
+	if len(l.Vertices) != 2 {
+		t.Fatalf("expected landmarks 0 and 1 only, got %v", l.Vertices)
+	}
+}
+
+func TestLandmarks_RoundTrip(t *testing.T) {
+	g := makeSparseGraph(25, 70, 167)
+	l := NewLandmarks(g, 3, LandmarksRandom, 5)
+	var buf bytes.Buffer
+	if _, err := l.WriteTo(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	data := buf.Bytes()
+	got, err := ReadLandmarks(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !reflect.DeepEqual(got, l) {
+		t.Fatalf("round trip mismatch")
+	}
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	if _, err := ReadLandmarks(bytes.NewReader(corrupt)); !errors.Is(err, ErrSnapshotFormat) {
+		t.Fatalf("expected ErrSnapshotFormat, got %v", err)
+	}
+
+	// A header declaring a huge table must fail on the missing payload rather
+	// than allocate it up front.
+	huge := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(huge[16:], 1<<20)
+	binary.LittleEndian.PutUint64(huge[24:], 1<<30)
+	if _, err := ReadLandmarks(bytes.NewReader(huge)); !errors.Is(err, ErrSnapshotFormat) {
+		t.Fatalf("expected truncation error for huge header, got %v", err)
+	}
+	if _, err := ReadLandmarks(bytes.NewReader(data[:len(data)-1])); !errors.Is(err, ErrSnapshotFormat) {
+		t.Fatalf("expected truncation error, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package bmssp
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+)
+
+// floydWarshallMaxVertices is the largest graph for which
+// AllPairsShortestPaths considers Floyd-Warshall. Beyond it the cubic running
+// time outweighs its tight inner loop even on complete graphs.
+const floydWarshallMaxVertices = 1 << 9
+
+// AllPairs holds the shortest distance between every ordered pair of
+// vertices. Distances and Predecessors are stored row-major: the distance from
+// u to v is Distances[u*Vertices+v], and Predecessors[u*Vertices+v] is the
+// vertex before v on a shortest path from u, or -1 if there is none.
+type AllPairs struct {
+	Vertices     int
+	Distances    []float64
+	Predecessors []int
+}
+
+func newAllPairs(n int) *AllPairs {
+	return &AllPairs{
+		Vertices:     n,
+		Distances:    make([]float64, n*n),
+		Predecessors: make([]int, n*n),
+	}
+}
+
+// At returns the shortest distance from u to v.
+func (a *AllPairs) At(u, v int) float64 {
+	return a.Distances[u*a.Vertices+v]
+}
+
+// Row returns the distances from u to every vertex. The slice aliases the
+// matrix.
+func (a *AllPairs) Row(u int) []float64 {
+	return a.Distances[u*a.Vertices : (u+1)*a.Vertices]
+}
+
+// Path returns a shortest path from u to v, or nil if v is unreachable.
+func (a *AllPairs) Path(u, v int) []int {
+	if math.IsInf(a.At(u, v), 1) {
+		return nil
+	}
+	return buildPath(a.Predecessors[u*a.Vertices:(u+1)*a.Vertices], u, v)
+}
+
+// Tree returns the shortest-path tree of source. Its arrays alias the matrix.
+func (a *AllPairs) Tree(source int) *ShortestPathTree {
+	row := source * a.Vertices
+	return &ShortestPathTree{
+		Source:       source,
+		Distances:    a.Distances[row : row+a.Vertices],
+		Predecessors: a.Predecessors[row : row+a.Vertices],
+	}
+}
+
+// WriteCSV writes the distance matrix as CSV: a header row of vertex ids, then
+// one row per source starting with its id. Unreachable pairs are written as
+// +Inf.
+func (a *AllPairs) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, a.Vertices+1)
+	for v := 0; v < a.Vertices; v++ {
+		record[v+1] = strconv.Itoa(v)
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for u := 0; u < a.Vertices; u++ {
+		record[0] = strconv.Itoa(u)
+		for v, d := range a.Row(u) {
+			record[v+1] = strconv.FormatFloat(d, 'g', -1, 64)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// AllPairsShortestPaths computes shortest paths between all pairs of vertices
+// of g. With AlgorithmAuto, small dense graphs use FloydWarshall; otherwise one
+// single-source computation per vertex runs on a Pool of the given number of
+// workers, with Dijkstra on dense graphs, where BMSSP has no advantage, and
+// BMSSP subject to the usual fallback threshold on sparse ones. An explicit
+// Algorithm in opts is passed on to the pool unchanged.
+func AllPairsShortestPaths(ctx context.Context, g *Graph, workers int, opts Options) (*AllPairs, error) {
+	c := g.CSR()
+	n := c.Vertices
+	if opts.Algorithm == AlgorithmAuto {
+		if n <= floydWarshallMaxVertices && 4*c.Edges >= n*n {
+			return floydWarshall(ctx, c)
+		}
+		if denseGraph(c) {
+			opts.Algorithm = AlgorithmDijkstra
+		}
+	}
+
+	a := newAllPairs(n)
+	pool := NewCSRPool(c, workers, opts)
+	err := pool.run(ctx, n, func(s *Solver, source int) {
+		tree, err := s.SolveAllContext(ctx, source)
+		if err != nil {
+			return
+		}
+		copy(a.Distances[source*n:], tree.Distances)
+		copy(a.Predecessors[source*n:], tree.Predecessors)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// denseGraph reports whether c has enough edges for Dijkstra's O(m + n log n)
+// to beat the O(m log^(2/3) n) bound of BMSSP, i.e. m > n log^(1/3) n.
+func denseGraph(c *CSRGraph) bool {
+	n := float64(c.Vertices)
+	return c.Vertices > 1 && float64(c.Edges) > n*math.Cbrt(math.Log2(n))
+}
+
+// FloydWarshall computes shortest paths between all pairs of vertices in
+// O(n^3) time and O(n^2) memory. It is the fastest choice for small dense
+// graphs and, unlike the other solvers, also accepts negative weights as long
+// as there is no negative cycle.
+func FloydWarshall(g *Graph) *AllPairs {
+	a, _ := floydWarshall(context.Background(), g.CSR())
+	return a
+}
+
+// FloydWarshallContext is like FloydWarshall but gives up once ctx is done and
+// returns ctx.Err().
+func FloydWarshallContext(ctx context.Context, g *Graph) (*AllPairs, error) {
+	return floydWarshall(ctx, g.CSR())
+}
+
+func floydWarshall(ctx context.Context, c *CSRGraph) (*AllPairs, error) {
+	n := c.Vertices
+	a := newAllPairs(n)
+	dist, pred := a.Distances, a.Predecessors
+	for i := range dist {
+		dist[i] = math.Inf(1)
+		pred[i] = -1
+	}
+	for u := 0; u < n; u++ {
+		dist[u*n+u] = 0
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			v := c.Targets[e]
+			if w := c.Weights[e]; v != u && w < dist[u*n+v] {
+				dist[u*n+v] = w
+				pred[u*n+v] = u
+			}
+		}
+	}
+
+	for k := 0; k < n; k++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		rowK := dist[k*n : (k+1)*n]
+		predK := pred[k*n : (k+1)*n]
+		for i := 0; i < n; i++ {
+			dik := dist[i*n+k]
+			if math.IsInf(dik, 1) {
+				continue
+			}
+			rowI := dist[i*n : (i+1)*n]
+			predI := pred[i*n : (i+1)*n]
+			for j, dkj := range rowK {
+				if d := dik + dkj; d < rowI[j] {
+					rowI[j] = d
+					predI[j] = predK[j]
+				}
+			}
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,86 @@
+package bmssp
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func assertAllPairs(t *testing.T, g *Graph, a *AllPairs) {
+	t.Helper()
+	for u := 0; u < g.Vertices; u++ {
+		for v := 0; v < g.Vertices; v++ {
+			want, _ := Dijkstra(g, u, v)
+			got := a.At(u, v)
+			if math.IsInf(want, 1) {
+				if !math.IsInf(got, 1) || a.Path(u, v) != nil {
+					t.Fatalf("expected %d->%d unreachable, got %f", u, v, got)
+				}
+				continue
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("distance mismatch %d->%d: %f vs %f", u, v, got, want)
+			}
+			assertValidPath(t, g, u, v, want, a.Path(u, v))
+		}
+	}
+}
+
+func TestAllPairsShortestPaths(t *testing.T) {
+	rng := rand.New(rand.NewSource(201))
+	for iter := 0; iter < 6; iter++ {
+		n := 10 + rng.Intn(40)
+		g := makeSparseGraph(n, 2*n+rng.Intn(n*n/2), rng.Int63())
+		for _, opts := range []Options{{}, {Algorithm: AlgorithmBMSSP}, {Algorithm: AlgorithmDijkstra}} {
+			a, err := AllPairsShortestPaths(context.Background(), g, 4, opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertAllPairs(t, g, a)
+		}
+		assertAllPairs(t, g, FloydWarshall(g))
+	}
+}
+
+func TestFloydWarshall_NegativeWeights(t *testing.T) {
+	base := makeSparseGraph(25, 80, 203)
+	g, _ := makePotentialGraph(base, 205)
+	a := FloydWarshall(g)
+	for u := 0; u < g.Vertices; u++ {
+		tree, err := BellmanFord(g, u)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for v := 0; v < g.Vertices; v++ {
+			want := tree.DistanceTo(v)
+			if got := a.At(u, v); got != want && math.Abs(got-want) > 1e-9 {
+				t.Fatalf("distance mismatch %d->%d: %f vs %f", u, v, got, want)
+			}
+		}
+	}
+}
+
+func TestAllPairs_WriteCSV(t *testing.T) {
+	g := NewGraph(3)
+	g.AddEdge(0, 1, 1.5)
+	g.AddEdge(1, 2, 2)
+	var buf bytes.Buffer
+	if err := FloydWarshall(g).WriteCSV(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := ",0,1,2\n0,0,1.5,3.5\n1,+Inf,0,2\n2,+Inf,+Inf,0\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+}
+
+func TestAllPairsShortestPaths_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g := makeSparseGraph(50, 100, 207)
+	if _, err := AllPairsShortestPaths(ctx, g, 2, Options{}); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
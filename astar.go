@@ -0,0 +1,171 @@
+package bmssp
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Heuristic estimates the remaining distance from v to the goal of an A*
+// search. It must never overestimate (admissible) for A* to return shortest
+// paths; if it is also consistent, every vertex is settled at most once.
+type Heuristic func(v int) float64
+
+// ErrInconsistentHeuristic is returned by CheckHeuristic for a heuristic that
+// violates h(u) <= w(u, v) + h(v) on some edge or is not zero at the goal.
+var ErrInconsistentHeuristic = errors.New("bmssp: inconsistent heuristic")
+
+// Point is a planar coordinate.
+type Point struct {
+	X, Y float64
+}
+
+// LatLng is a geographic coordinate in degrees.
+type LatLng struct {
+	Lat, Lng float64
+}
+
+// earthRadius is the mean Earth radius in metres.
+const earthRadius = 6371008.8
+
+// EuclideanHeuristic returns the straight-line distance from each vertex to
+// goal multiplied by scale. It is admissible when no edge weight is smaller
+// than scale times the straight-line length of the edge, e.g. with scale set
+// to 1/maxSpeed for travel-time weights.
+func EuclideanHeuristic(coords []Point, goal int, scale float64) Heuristic {
+	target := coords[goal]
+	return func(v int) float64 {
+		return scale * math.Hypot(coords[v].X-target.X, coords[v].Y-target.Y)
+	}
+}
+
+// HaversineHeuristic returns the great-circle distance in metres from each
+// vertex to goal multiplied by scale, with the same admissibility condition as
+// EuclideanHeuristic.
+func HaversineHeuristic(coords []LatLng, goal int, scale float64) Heuristic {
+	target := coords[goal]
+	lat2 := target.Lat * math.Pi / 180
+	return func(v int) float64 {
+		lat1 := coords[v].Lat * math.Pi / 180
+		dLat := lat2 - lat1
+		dLng := (target.Lng - coords[v].Lng) * math.Pi / 180
+		a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+		return scale * 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+	}
+}
+
+// AStar answers a point-to-point query with A* search guided by h. With an
+// admissible heuristic it returns the same distance as Dijkstra; the path may
+// differ between equally short alternatives. Vertices whose heuristic is +Inf
+// are treated as unable to reach goal and never expanded.
+func AStar(g *Graph, source, goal int, h Heuristic) (float64, []int) {
+	dist, path, _ := aStarSearch(context.Background(), g.CSR(), source, goal, h)
+	return dist, path
+}
+
+// AStarCSR is AStar on a compressed graph.
+func AStarCSR(c *CSRGraph, source, goal int, h Heuristic) (float64, []int) {
+	dist, path, _ := aStarSearch(context.Background(), c, source, goal, h)
+	return dist, path
+}
+
+// AStarContext is like AStar but gives up once ctx is done and returns
+// ctx.Err().
+func AStarContext(ctx context.Context, g *Graph, source, goal int, h Heuristic) (float64, []int, error) {
+	return aStarSearch(ctx, g.CSR(), source, goal, h)
+}
+
+// CheckHeuristic verifies that h is consistent on g for goal: zero at the goal
+// and h(u) <= w + h(v) for every edge u->v of weight w, up to a small relative
+// tolerance for rounding. Consistent heuristics are admissible.
+func CheckHeuristic(g *Graph, goal int, h Heuristic) error {
+	return checkHeuristic(g.CSR(), goal, h)
+}
+
+func checkHeuristic(c *CSRGraph, goal int, h Heuristic) error {
+	if hg := h(goal); hg != 0 {
+		return fmt.Errorf("%w: h(goal)=%g", ErrInconsistentHeuristic, hg)
+	}
+	for u := 0; u < c.Vertices; u++ {
+		hu := h(u)
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			if err := checkEdgeHeuristic(u, c.Targets[e], c.Weights[e], hu, h(c.Targets[e])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkEdgeHeuristic(u, v int, w, hu, hv float64) error {
+	limit := w + hv
+	if hu > limit+1e-9*math.Max(1, math.Abs(limit)) {
+		return fmt.Errorf("%w: edge %d->%d: h(%d)=%g > %g + h(%d)=%g", ErrInconsistentHeuristic, u, v, u, hu, w, v, hv)
+	}
+	return nil
+}
+
+// aStarSearch is Dijkstra ordered by dist + h. Queue entries carry that
+// priority; an entry is stale once the vertex has been reached more cheaply.
+// A vertex may be expanded again after an improvement, which keeps the search
+// exact for admissible but inconsistent heuristics. Builds with the bmsspdebug
+// tag panic on the first edge that violates consistency.
+func aStarSearch(ctx context.Context, g *CSRGraph, source, goal int, h Heuristic) (float64, []int, error) {
+	n := g.Vertices
+	dist := make([]float64, n)
+	prev := make([]int, n)
+	for i := 0; i < n; i++ {
+		dist[i] = math.Inf(1)
+		prev[i] = -1
+	}
+	dist[source] = 0
+
+	pq := &dijkstraQueue[float64]{}
+	if hs := h(source); !math.IsInf(hs, 1) {
+		heap.Push(pq, &dijkstraItem[float64]{Vertex: source, Distance: hs})
+	}
+
+	done := ctx.Done()
+	for pq.Len() > 0 {
+		select {
+		case <-done:
+			return math.Inf(1), nil, ctx.Err()
+		default:
+		}
+
+		item := heap.Pop(pq).(*dijkstraItem[float64])
+		u := item.Vertex
+		hu := h(u)
+		if item.Distance > dist[u]+hu {
+			continue
+		}
+		if u == goal {
+			break
+		}
+
+		for e := g.Offsets[u]; e < g.Offsets[u+1]; e++ {
+			v := g.Targets[e]
+			w := g.Weights[e]
+			hv := h(v)
+			if debugChecks {
+				if err := checkEdgeHeuristic(u, v, w, hu, hv); err != nil {
+					panic(err)
+				}
+			}
+			newDist := dist[u] + w
+			if newDist >= dist[v] || math.IsInf(hv, 1) {
+				continue
+			}
+			dist[v] = newDist
+			prev[v] = u
+			heap.Push(pq, &dijkstraItem[float64]{Vertex: v, Distance: newDist + hv})
+		}
+	}
+
+	if math.IsInf(dist[goal], 1) {
+		return math.Inf(1), nil, nil
+	}
+	return dist[goal], buildPath(prev, source, goal), nil
+}
@@ -0,0 +1,97 @@
+package bmssp
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// makeGeometricGraph places n vertices in the unit square and connects random
+// pairs with weights at least their Euclidean length.
+func makeGeometricGraph(n, m int, seed int64) (*Graph, []Point) {
+	rng := rand.New(rand.NewSource(seed))
+	coords := make([]Point, n)
+	for i := range coords {
+		coords[i] = Point{X: rng.Float64(), Y: rng.Float64()}
+	}
+	g := NewGraph(n)
+	for i := 0; i < m; i++ {
+		u, v := rng.Intn(n), rng.Intn(n)
+		length := math.Hypot(coords[u].X-coords[v].X, coords[u].Y-coords[v].Y)
+		g.AddEdge(u, v, length*(1+rng.Float64()))
+	}
+	return g, coords
+}
+
+func TestAStar_Euclidean(t *testing.T) {
+	rng := rand.New(rand.NewSource(151))
+	for iter := 0; iter < 20; iter++ {
+		n := 10 + rng.Intn(60)
+		g, coords := makeGeometricGraph(n, 3*n, rng.Int63())
+		for trial := 0; trial < 10; trial++ {
+			source, goal := rng.Intn(n), rng.Intn(n)
+			h := EuclideanHeuristic(coords, goal, 1)
+			if err := CheckHeuristic(g, goal, h); err != nil {
+				t.Fatalf("expected consistent heuristic: %v", err)
+			}
+			want, _ := Dijkstra(g, source, goal)
+			got, path := AStar(g, source, goal, h)
+			if math.IsInf(want, 1) {
+				if !math.IsInf(got, 1) || path != nil {
+					t.Fatalf("expected no path %d->%d, got %f %v", source, goal, got, path)
+				}
+				continue
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("distance mismatch %d->%d: %f vs %f", source, goal, got, want)
+			}
+			assertValidPath(t, g, source, goal, got, path)
+		}
+	}
+}
+
+func TestAStar_ZeroHeuristic(t *testing.T) {
+	g := makeSparseGraph(50, 200, 153)
+	zero := func(int) float64 { return 0 }
+	for goal := 0; goal < g.Vertices; goal++ {
+		want, _ := Dijkstra(g, 0, goal)
+		got, _ := AStarCSR(g.CSR(), 0, goal, zero)
+		if got != want && !(math.IsInf(got, 1) && math.IsInf(want, 1)) {
+			t.Fatalf("distance mismatch 0->%d: %f vs %f", goal, got, want)
+		}
+	}
+}
+
+func TestAStar_InconsistentHeuristic(t *testing.T) {
+	// The direct edge 0->1 is expanded before the cheaper route through 2. The
+	// heuristic is admissible but not consistent on 2->1, so 1 must be reopened.
+	g := NewGraph(4)
+	g.AddEdge(0, 1, 2)
+	g.AddEdge(0, 2, 1)
+	g.AddEdge(2, 1, 0.5)
+	g.AddEdge(1, 3, 1)
+	h := func(v int) float64 { return []float64{0, 0, 1.4, 0}[v] }
+	if err := CheckHeuristic(g, 3, h); !errors.Is(err, ErrInconsistentHeuristic) {
+		t.Fatalf("expected ErrInconsistentHeuristic, got %v", err)
+	}
+	if debugChecks {
+		t.Skip("debug builds panic on inconsistent heuristics")
+	}
+	dist, path := AStar(g, 0, 3, h)
+	if dist != 2.5 {
+		t.Fatalf("expected distance 2.5, got %f (%v)", dist, path)
+	}
+	assertValidPath(t, g, 0, 3, dist, path)
+}
+
+func TestHaversineHeuristic(t *testing.T) {
+	coords := []LatLng{{Lat: 51.5074, Lng: -0.1278}, {Lat: 48.8566, Lng: 2.3522}}
+	got := HaversineHeuristic(coords, 1, 1)(0)
+	if math.Abs(got-343.5e3) > 1e3 {
+		t.Fatalf("expected London-Paris around 343.5km, got %f m", got)
+	}
+	if d := HaversineHeuristic(coords, 1, 1)(1); d != 0 {
+		t.Fatalf("expected zero at goal, got %f", d)
+	}
+}
@@ -1,6 +1,7 @@
 package bmssp
 
 import (
+	"context"
 	"math/rand"
 	"testing"
 )
@@ -27,6 +28,18 @@ func BenchmarkDijkstraSparse(b *testing.B) {
 	}
 }
 
+func BenchmarkBidirectionalDijkstraSparse(b *testing.B) {
+	g := makeSparseGraph(benchNodes, benchEdges, 1)
+	pairs := makePairs(benchNodes, benchPairs, 2)
+	g.ReverseCSR()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := pairs[i%len(pairs)]
+		BidirectionalDijkstra(g, p.source, p.target)
+	}
+}
+
 func BenchmarkBMSSPSparse(b *testing.B) {
 	g := makeSparseGraph(benchNodes, benchEdges, 3)
 	pairs := makePairs(benchNodes, benchPairs, 4)
@@ -40,6 +53,19 @@ func BenchmarkBMSSPSparse(b *testing.B) {
 	}
 }
 
+func BenchmarkBMSSPSparseCSR(b *testing.B) {
+	g := makeSparseGraph(benchNodes, benchEdges, 3)
+	pairs := makePairs(benchNodes, benchPairs, 4)
+	solver := NewCSRSolver(NewCSRGraph(g))
+	solver.ForceBMSSP = true
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p := pairs[i%len(pairs)]
+		solver.Solve(p.source, p.target)
+	}
+}
+
 func BenchmarkBMSSPSparseCore(b *testing.B) {
 	g := makeSparseGraph(benchNodes, benchEdges, 5)
 	pairs := makePairs(benchNodes, benchPairs, 6)
@@ -57,7 +83,7 @@ func BenchmarkBMSSPSparseCore(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		p := mapped[i%len(mapped)]
-		solver.solveBMSSP(p.source, p.target)
+		solver.solveBMSSP(context.Background(), p.source, p.target)
 	}
 }
 
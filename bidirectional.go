@@ -0,0 +1,149 @@
+package bmssp
+
+import (
+	"container/heap"
+	"context"
+)
+
+// BidirectionalDijkstra answers a point-to-point query by growing one Dijkstra
+// search forward from source and one backward from goal over the reverse
+// graph, which is cached on g. It returns the same result as Dijkstra, usually
+// after settling far fewer vertices.
+func BidirectionalDijkstra[W Weight](g *GraphOf[W], source, goal int) (W, []int) {
+	c := g.CSR()
+	dist, path, _ := bidirectionalSearch(context.Background(), c, c.Reverse(), source, goal)
+	return dist, path
+}
+
+// BidirectionalDijkstraCSR is BidirectionalDijkstra on a compressed graph.
+func BidirectionalDijkstraCSR[W Weight](c *CSRGraphOf[W], source, goal int) (W, []int) {
+	dist, path, _ := bidirectionalSearch(context.Background(), c, c.Reverse(), source, goal)
+	return dist, path
+}
+
+// BidirectionalDijkstraContext is like BidirectionalDijkstra but gives up once
+// ctx is done and returns ctx.Err().
+func BidirectionalDijkstraContext[W Weight](ctx context.Context, g *GraphOf[W], source, goal int) (W, []int, error) {
+	c := g.CSR()
+	return bidirectionalSearch(ctx, c, c.Reverse(), source, goal)
+}
+
+// bidirectionalSearch alternates between the forward search over fwd and the
+// backward search over bwd, always advancing the side whose next vertex is
+// closer. best is the length of the shortest source-goal path seen so far; it
+// is updated whenever a vertex receives a new distance from either side. Once
+// the two queue minima add up to at least best, no shorter path can exist.
+// Sums that do not fit W are skipped, as in dijkstraSearch.
+func bidirectionalSearch[W Weight](ctx context.Context, fwd, bwd *CSRGraphOf[W], source, goal int) (W, []int, error) {
+	n := fwd.Vertices
+	inf := infinity[W]()
+	overflowed := false
+	distF := make([]W, n)
+	distB := make([]W, n)
+	prev := make([]int, n)
+	next := make([]int, n)
+	for i := 0; i < n; i++ {
+		distF[i] = inf
+		distB[i] = inf
+		prev[i] = -1
+		next[i] = -1
+	}
+	distF[source] = 0
+	distB[goal] = 0
+
+	best := inf
+	meet := -1
+	if source == goal {
+		best, meet = 0, source
+	}
+
+	pqF := &dijkstraQueue[W]{}
+	pqB := &dijkstraQueue[W]{}
+	heap.Push(pqF, &dijkstraItem[W]{Vertex: source, Distance: 0})
+	heap.Push(pqB, &dijkstraItem[W]{Vertex: goal, Distance: 0})
+
+	done := ctx.Done()
+	for {
+		select {
+		case <-done:
+			return inf, nil, ctx.Err()
+		default:
+		}
+
+		topF := queueMin(pqF, distF)
+		topB := queueMin(pqB, distB)
+		if topF == inf || topB == inf {
+			break
+		}
+		sum, ok := addWeights(topF, topB, inf)
+		if !ok {
+			// Every path still to be found is too long for W.
+			overflowed = true
+			break
+		}
+		if sum >= best {
+			break
+		}
+
+		// Advance the side with the closer frontier. Each step settles one
+		// vertex u and relaxes its edges in the direction of that side.
+		g, pq, dist, other, link := fwd, pqF, distF, distB, prev
+		if topB < topF {
+			g, pq, dist, other, link = bwd, pqB, distB, distF, next
+		}
+		u := heap.Pop(pq).(*dijkstraItem[W]).Vertex
+		for e := g.Offsets[u]; e < g.Offsets[u+1]; e++ {
+			v := g.Targets[e]
+			newDist, ok := addWeights(dist[u], g.Weights[e], inf)
+			if !ok {
+				overflowed = true
+				continue
+			}
+			if newDist >= dist[v] {
+				continue
+			}
+			dist[v] = newDist
+			link[v] = u
+			heap.Push(pq, &dijkstraItem[W]{Vertex: v, Distance: newDist})
+			if other[v] == inf {
+				continue
+			}
+			total, ok := addWeights(newDist, other[v], inf)
+			if !ok {
+				overflowed = true
+				continue
+			}
+			if total < best {
+				best, meet = total, v
+			}
+		}
+	}
+
+	if meet == -1 {
+		if overflowed {
+			sources := []SourceOf[W]{{Vertex: source}}
+			err := checkOverflow(fwd, sources, nil, func(v int) bool { return v == goal })
+			return inf, nil, err
+		}
+		return inf, nil, nil
+	}
+
+	path := buildPath(prev, source, meet)
+	for v := next[meet]; v != -1; v = next[v] {
+		path = append(path, v)
+	}
+	return best, path, nil
+}
+
+// queueMin drops stale entries from the top of pq and returns the smallest
+// remaining distance, or infinity if the queue is exhausted.
+func queueMin[W Weight](pq *dijkstraQueue[W], dist []W) W {
+	for pq.Len() > 0 {
+		top := (*pq)[0]
+		if top.Distance <= dist[top.Vertex] {
+			return top.Distance
+		}
+		heap.Pop(pq)
+	}
+	return infinity[W]()
+}
@@ -0,0 +1,91 @@
+package bmssp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestBidirectionalDijkstra(t *testing.T) {
+	rng := rand.New(rand.NewSource(101))
+	for iter := 0; iter < 20; iter++ {
+		n := 10 + rng.Intn(40)
+		g := makeSparseGraph(n, 2*n+rng.Intn(3*n), rng.Int63())
+		if iter%4 == 0 {
+			// Zero weights exercise the stopping rule on ties.
+			g.AddEdge(rng.Intn(n), rng.Intn(n), 0)
+		}
+		for trial := 0; trial < 10; trial++ {
+			source, target := rng.Intn(n), rng.Intn(n)
+			want, _ := Dijkstra(g, source, target)
+			got, path := BidirectionalDijkstra(g, source, target)
+			if math.IsInf(want, 1) {
+				if !math.IsInf(got, 1) || path != nil {
+					t.Fatalf("expected no path %d->%d, got %f %v", source, target, got, path)
+				}
+				continue
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("distance mismatch %d->%d: %f vs %f", source, target, got, want)
+			}
+			assertValidPath(t, g, source, target, got, path)
+		}
+	}
+}
+
+func TestBidirectionalDijkstra_SameVertex(t *testing.T) {
+	g := NewGraph(2)
+	g.AddEdge(0, 1, 1)
+	dist, path := BidirectionalDijkstra(g, 1, 1)
+	if dist != 0 || len(path) != 1 || path[0] != 1 {
+		t.Fatalf("expected [1] at distance 0, got %f %v", dist, path)
+	}
+}
+
+func TestCSRGraph_Reverse(t *testing.T) {
+	g := makeSparseGraph(20, 60, 103)
+	rev := g.ReverseCSR()
+	if rev != g.ReverseCSR() {
+		t.Fatalf("expected reverse graph to be cached")
+	}
+	if rev.Edges != g.Edges {
+		t.Fatalf("edge count mismatch: %d vs %d", rev.Edges, g.Edges)
+	}
+	for u, edges := range g.Adj {
+		for _, edge := range edges {
+			found := false
+			for e := rev.Offsets[edge.To]; e < rev.Offsets[edge.To+1]; e++ {
+				if rev.Targets[e] == u && rev.Weights[e] == edge.Weight {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("edge %d->%d missing from reverse graph", u, edge.To)
+			}
+		}
+	}
+
+	g.AddEdge(0, 1, 1)
+	if g.ReverseCSR() == rev {
+		t.Fatalf("expected reverse graph to be rebuilt after AddEdge")
+	}
+}
+
+func TestSolver_BidirectionalFallback(t *testing.T) {
+	g := makeSparseGraph(60, 240, 107)
+	solver := NewSolverWithOptions(g, Options{BidirectionalFallback: true})
+	for target := 0; target < 60; target += 3 {
+		want, _ := Dijkstra(g, 5, target)
+		got, path := solver.Solve(5, target)
+		if math.IsInf(want, 1) {
+			if !math.IsInf(got, 1) {
+				t.Fatalf("expected no path to %d", target)
+			}
+			continue
+		}
+		if math.Abs(got-want) > 1e-9 {
+			t.Fatalf("distance mismatch to %d: %f vs %f", target, got, want)
+		}
+		assertValidPath(t, g, 5, target, got, path)
+	}
+}
@@ -1,25 +1,25 @@
 package bmssp
 
-type blockIndex struct {
-	root *blockNode
+type blockIndex[W Weight] struct {
+	root *blockNode[W]
 	seed uint32
 }
 
-type blockNode struct {
-	label    Label
+type blockNode[W Weight] struct {
+	label    LabelOf[W]
 	id       int
-	block    *block
+	block    *block[W]
 	priority uint32
-	left     *blockNode
-	right    *blockNode
+	left     *blockNode[W]
+	right    *blockNode[W]
 }
 
-func newBlockIndex() blockIndex {
-	return blockIndex{seed: 1}
+func newBlockIndex[W Weight]() blockIndex[W] {
+	return blockIndex[W]{seed: 1}
 }
 
-func (t *blockIndex) Insert(label Label, id int, b *block) {
-	node := &blockNode{
+func (t *blockIndex[W]) Insert(label LabelOf[W], id int, b *block[W]) {
+	node := &blockNode[W]{
 		label:    label,
 		id:       id,
 		block:    b,
@@ -28,13 +28,13 @@ func (t *blockIndex) Insert(label Label, id int, b *block) {
 	t.root = insertNode(t.root, node)
 }
 
-func (t *blockIndex) Delete(label Label, id int) {
+func (t *blockIndex[W]) Delete(label LabelOf[W], id int) {
 	t.root = deleteNode(t.root, label, id)
 }
 
-func (t *blockIndex) LowerBound(label Label) *block {
+func (t *blockIndex[W]) LowerBound(label LabelOf[W]) *block[W] {
 	node := t.root
-	var best *blockNode
+	var best *blockNode[W]
 	for node != nil {
 		if keyLess(node.label, node.id, label, -1) {
 			node = node.right
@@ -49,12 +49,12 @@ func (t *blockIndex) LowerBound(label Label) *block {
 	return best.block
 }
 
-func (t *blockIndex) nextPriority() uint32 {
+func (t *blockIndex[W]) nextPriority() uint32 {
 	t.seed = t.seed*1664525 + 1013904223
 	return t.seed
 }
 
-func keyLess(a Label, aid int, b Label, bid int) bool {
+func keyLess[W Weight](a LabelOf[W], aid int, b LabelOf[W], bid int) bool {
 	if a.Less(b) {
 		return true
 	}
@@ -64,7 +64,7 @@ func keyLess(a Label, aid int, b Label, bid int) bool {
 	return aid < bid
 }
 
-func insertNode(root *blockNode, node *blockNode) *blockNode {
+func insertNode[W Weight](root *blockNode[W], node *blockNode[W]) *blockNode[W] {
 	if root == nil {
 		return node
 	}
@@ -82,7 +82,7 @@ func insertNode(root *blockNode, node *blockNode) *blockNode {
 	return root
 }
 
-func deleteNode(root *blockNode, label Label, id int) *blockNode {
+func deleteNode[W Weight](root *blockNode[W], label LabelOf[W], id int) *blockNode[W] {
 	if root == nil {
 		return nil
 	}
@@ -97,7 +97,7 @@ func deleteNode(root *blockNode, label Label, id int) *blockNode {
 	return mergeNodes(root.left, root.right)
 }
 
-func mergeNodes(left, right *blockNode) *blockNode {
+func mergeNodes[W Weight](left, right *blockNode[W]) *blockNode[W] {
 	if left == nil {
 		return right
 	}
@@ -112,7 +112,7 @@ func mergeNodes(left, right *blockNode) *blockNode {
 	return right
 }
 
-func rotateRight(y *blockNode) *blockNode {
+func rotateRight[W Weight](y *blockNode[W]) *blockNode[W] {
 	x := y.left
 	t2 := x.right
 	x.right = y
@@ -120,7 +120,7 @@ func rotateRight(y *blockNode) *blockNode {
 	return x
 }
 
-func rotateLeft(x *blockNode) *blockNode {
+func rotateLeft[W Weight](x *blockNode[W]) *blockNode[W] {
 	y := x.right
 	t2 := y.left
 	y.left = x
@@ -105,3 +105,36 @@ func compareBMSSPToDijkstra(t *testing.T, g *Graph, source, target int) {
 	}
 	assertValidPath(t, g, source, target, bmDist, bmPath)
 }
+
+func TestBMSSP_DuplicateEdgesWithoutTransformation(t *testing.T) {
+	// Identical parallel edges relax their target twice in the same round,
+	// which must not leave the vertex in the frontier twice.
+	rng := rand.New(rand.NewSource(221))
+	for iter := 0; iter < 20; iter++ {
+		n := 10 + rng.Intn(50)
+		g := makeSparseGraph(n, 3*n, rng.Int63())
+		for i := 0; i < n/2; i++ {
+			u := rng.Intn(n)
+			if len(g.Adj[u]) > 0 {
+				edge := g.Adj[u][rng.Intn(len(g.Adj[u]))]
+				g.AddEdge(u, edge.To, edge.Weight)
+			}
+		}
+		solver := NewSolverWithOptions(g, Options{Algorithm: AlgorithmBMSSP, DisableTransformation: true})
+		for trial := 0; trial < 10; trial++ {
+			source, goal := rng.Intn(n), rng.Intn(n)
+			want, _ := Dijkstra(g, source, goal)
+			got, path := solver.Solve(source, goal)
+			if math.IsInf(want, 1) {
+				if !math.IsInf(got, 1) {
+					t.Fatalf("expected no path %d->%d, got %f", source, goal, got)
+				}
+				continue
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("distance mismatch %d->%d: %f vs %f", source, goal, got, want)
+			}
+			assertValidPath(t, g, source, goal, want, path)
+		}
+	}
+}
@@ -153,3 +153,40 @@ func pathDistance(g *Graph, path []int) (float64, bool) {
 	}
 	return total, true
 }
+
+func TestBMSSP_TransformationCache(t *testing.T) {
+	g := NewGraph(4)
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(0, 3, 10)
+
+	solver := NewSolver(g)
+	solver.ForceBMSSP = true
+
+	dist, _ := solver.Solve(0, 3)
+	if dist != 10 {
+		t.Fatalf("expected distance 10, got %f", dist)
+	}
+	cached := solver.transform
+	if cached == nil {
+		t.Fatalf("expected transformation to be cached")
+	}
+
+	dist, _ = solver.Solve(0, 2)
+	if dist != 2 {
+		t.Fatalf("expected distance 2, got %f", dist)
+	}
+	if solver.transform != cached {
+		t.Fatalf("expected cached transformation to be reused")
+	}
+
+	g.AddEdge(2, 3, 1)
+	dist, path := solver.Solve(0, 3)
+	if dist != 3 {
+		t.Fatalf("expected distance 3 after AddEdge, got %f", dist)
+	}
+	assertValidPath(t, g, 0, 3, dist, path)
+	if solver.transform == cached {
+		t.Fatalf("expected transformation to be rebuilt after AddEdge")
+	}
+}
@@ -0,0 +1,64 @@
+package bmssp
+
+import (
+	"context"
+	"sort"
+)
+
+// BoundedResultOf holds the vertices whose distance from the closest source is
+// strictly below Bound. Distances, Predecessors and Origins are defined as in
+// MultiSourceResult, except that every vertex at distance Bound or beyond is
+// reported as unreachable. Vertices lists the vertices within the bound in
+// increasing order of distance.
+type BoundedResultOf[W Weight] struct {
+	MultiSourceResultOf[W]
+	Bound    W
+	Vertices []int
+}
+
+// BoundedResult is the result of a bounded search over float64 weights.
+type BoundedResult = BoundedResultOf[float64]
+
+// WithinDistance returns every vertex whose distance from the closest of the
+// given sources is less than bound. This is the bounded multi-source shortest
+// path primitive of the paper: the search stops at the bound instead of
+// exploring the whole graph. It returns nil if a source is not a vertex of the
+// graph, a source offset is NaN or bound is NaN.
+func (s *SolverOf[W]) WithinDistance(sources []SourceOf[W], bound W) *BoundedResultOf[W] {
+	result, _ := s.WithinDistanceContext(context.Background(), sources, bound)
+	return result
+}
+
+// WithinDistanceContext is like WithinDistance but reports invalid input with
+// ErrVertexOutOfRange or ErrNaNDistance, and gives up once ctx is done and
+// returns ctx.Err().
+func (s *SolverOf[W]) WithinDistanceContext(ctx context.Context, sources []SourceOf[W], bound W) (*BoundedResultOf[W], error) {
+	dist, prev, err := s.search(ctx, sources, bound, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	vertices := make([]int, 0)
+	for v := range dist {
+		if dist[v] != s.inf {
+			vertices = append(vertices, v)
+		}
+	}
+	sort.Slice(vertices, func(i, j int) bool {
+		a, b := vertices[i], vertices[j]
+		if dist[a] != dist[b] {
+			return dist[a] < dist[b]
+		}
+		return a < b
+	})
+
+	return &BoundedResultOf[W]{
+		MultiSourceResultOf: MultiSourceResultOf[W]{
+			Distances:    dist,
+			Predecessors: prev,
+			Origins:      treeOrigins(dist, prev),
+		},
+		Bound:    bound,
+		Vertices: vertices,
+	}, nil
+}
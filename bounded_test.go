@@ -0,0 +1,57 @@
+package bmssp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestWithinDistance(t *testing.T) {
+	rng := rand.New(rand.NewSource(44))
+	for iter := 0; iter < 15; iter++ {
+		n := 15 + rng.Intn(15)
+		g := makeSparseGraph(n, 3*n*(n-1)/20, rng.Int63())
+
+		sources := []Source{{Vertex: rng.Intn(n)}, {Vertex: rng.Intn(n), Offset: rng.Float64() * 3}}
+		bound := 5 + rng.Float64()*10
+
+		full := NewSolver(g).SolveMultiSource(sources)
+		for _, force := range []bool{false, true} {
+			solver := NewSolver(g)
+			solver.ForceBMSSP = force
+			result := solver.WithinDistance(sources, bound)
+			if result == nil {
+				t.Fatalf("expected result for sources %v", sources)
+			}
+
+			count := 0
+			for v := 0; v < n; v++ {
+				want := full.DistanceTo(v)
+				got := result.DistanceTo(v)
+				if want >= bound {
+					if !math.IsInf(got, 1) || result.PathTo(v) != nil {
+						t.Fatalf("vertex %d at distance %f should be outside bound %f, got %f", v, want, bound, got)
+					}
+					continue
+				}
+				count++
+				if math.Abs(got-want) > 1e-9 {
+					t.Fatalf("distance mismatch at %d: got %f want %f", v, got, want)
+				}
+				path := result.PathTo(v)
+				if len(path) == 0 || path[len(path)-1] != v {
+					t.Fatalf("invalid path to %d: %v", v, path)
+				}
+			}
+
+			if len(result.Vertices) != count {
+				t.Fatalf("expected %d vertices within bound, got %d", count, len(result.Vertices))
+			}
+			for i := 1; i < len(result.Vertices); i++ {
+				if result.DistanceTo(result.Vertices[i-1]) > result.DistanceTo(result.Vertices[i]) {
+					t.Fatalf("vertices not ordered by distance: %v", result.Vertices)
+				}
+			}
+		}
+	}
+}
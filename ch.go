@@ -0,0 +1,629 @@
+package bmssp
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"math"
+)
+
+// ContractionHierarchy is the result of contraction hierarchies (CH)
+// preprocessing. Vertices are contracted one by one in order of Rank; every
+// contraction adds shortcut edges that preserve the distances between the
+// remaining vertices. A query then only ever moves to higher-ranked vertices,
+// searching Up forward from the source and Down backward from the goal.
+//
+// Up holds every hierarchy edge u->v with Rank[u] < Rank[v] at u. Down holds
+// every hierarchy edge u->v with Rank[u] > Rank[v] reversed, at v with target
+// u. UpMiddle and DownMiddle give, per edge, the vertex a shortcut bypasses, or
+// -1 for an original edge. Parallel edges are merged by minimum weight and
+// self-loops are dropped, since neither can be part of a shortest path.
+type ContractionHierarchy struct {
+	Rank       []int
+	Up         *CSRGraph
+	UpMiddle   []int
+	Down       *CSRGraph
+	DownMiddle []int
+}
+
+// Witness searches settle at most this many vertices when contracting a
+// vertex and when merely estimating its priority. A search that gives up early
+// only adds a superfluous shortcut, never a wrong one.
+const (
+	witnessSettleLimit  = 1 << 9
+	prioritySettleLimit = 1 << 5
+)
+
+// NewContractionHierarchy orders and contracts the vertices of g. The order is
+// chosen greedily by edge difference (shortcuts added minus edges removed) plus
+// the number of already contracted neighbours, with lazy priority updates.
+func NewContractionHierarchy(g *Graph) *ContractionHierarchy {
+	h, _ := NewContractionHierarchyContext(context.Background(), g)
+	return h
+}
+
+// NewContractionHierarchyContext is like NewContractionHierarchy but gives up
+// once ctx is done and returns ctx.Err().
+func NewContractionHierarchyContext(ctx context.Context, g *Graph) (*ContractionHierarchy, error) {
+	b := newCHBuilder(g.CSR())
+	n := len(b.out)
+
+	priority := make([]float64, n)
+	pq := &dijkstraQueue[float64]{}
+	for v := 0; v < n; v++ {
+		if err := checkContext(ctx, v); err != nil {
+			return nil, err
+		}
+		priority[v] = b.priority(v)
+		heap.Push(pq, &dijkstraItem[float64]{Vertex: v, Distance: priority[v]})
+	}
+
+	rank := make([]int, n)
+	for next := 0; pq.Len() > 0; {
+		if err := checkContext(ctx, next); err != nil {
+			return nil, err
+		}
+		item := heap.Pop(pq).(*dijkstraItem[float64])
+		v := item.Vertex
+		if b.contracted[v] || item.Distance != priority[v] {
+			continue
+		}
+		// Lazy update: the priority may be out of date. If the recomputed
+		// value no longer beats the next candidate, requeue the vertex.
+		priority[v] = b.priority(v)
+		if pq.Len() > 0 && priority[v] > (*pq)[0].Distance {
+			heap.Push(pq, &dijkstraItem[float64]{Vertex: v, Distance: priority[v]})
+			continue
+		}
+
+		neighbors := b.neighbors(v)
+		b.contract(v)
+		rank[v] = next
+		next++
+
+		for _, u := range neighbors {
+			b.contractedNeighbors[u]++
+		}
+	}
+	return b.hierarchy(rank), nil
+}
+
+// chArc is an edge of the graph being contracted; middle is the vertex a
+// shortcut bypasses, or -1.
+type chArc struct {
+	to     int
+	weight float64
+	middle int
+}
+
+type chShortcut struct {
+	from, to int
+	weight   float64
+}
+
+// chBuilder holds the remaining graph during contraction. Contracting a vertex
+// moves all its arcs from the working lists to final, so witness searches only
+// ever see uncontracted vertices and final ends up with every original edge
+// and shortcut of the hierarchy.
+type chBuilder struct {
+	out                 [][]chArc
+	in                  [][]int
+	contracted          []bool
+	contractedNeighbors []int
+	final               []chShortcut
+	finalMiddle         []int
+
+	dist    []float64
+	touched []int
+	target  []bool
+}
+
+func newCHBuilder(c *CSRGraph) *chBuilder {
+	n := c.Vertices
+	b := &chBuilder{
+		out:                 make([][]chArc, n),
+		in:                  make([][]int, n),
+		contracted:          make([]bool, n),
+		contractedNeighbors: make([]int, n),
+		dist:                make([]float64, n),
+		target:              make([]bool, n),
+	}
+	for v := range b.dist {
+		b.dist[v] = math.Inf(1)
+	}
+	for u := 0; u < n; u++ {
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			if v := c.Targets[e]; v != u {
+				b.addArc(u, v, c.Weights[e], -1)
+			}
+		}
+	}
+	return b
+}
+
+// addArc adds the arc u->v or lowers the weight of an existing one.
+func (b *chBuilder) addArc(u, v int, w float64, middle int) {
+	for i := range b.out[u] {
+		if a := &b.out[u][i]; a.to == v {
+			if w < a.weight {
+				a.weight, a.middle = w, middle
+			}
+			return
+		}
+	}
+	b.out[u] = append(b.out[u], chArc{to: v, weight: w, middle: middle})
+	b.in[v] = append(b.in[v], u)
+}
+
+func (b *chBuilder) weight(u, v int) float64 {
+	for _, a := range b.out[u] {
+		if a.to == v {
+			return a.weight
+		}
+	}
+	return math.Inf(1)
+}
+
+// contract adds the shortcuts needed to remove v and moves the arcs of v to
+// final.
+func (b *chBuilder) contract(v int) {
+	for _, s := range b.shortcuts(v, witnessSettleLimit) {
+		b.addArc(s.from, s.to, s.weight, v)
+	}
+	for _, a := range b.out[v] {
+		b.finish(v, a)
+		b.in[a.to] = removeInt(b.in[a.to], v)
+	}
+	for _, u := range b.in[v] {
+		for i, a := range b.out[u] {
+			if a.to == v {
+				b.finish(u, a)
+				last := len(b.out[u]) - 1
+				b.out[u][i] = b.out[u][last]
+				b.out[u] = b.out[u][:last]
+				break
+			}
+		}
+	}
+	b.out[v], b.in[v] = nil, nil
+	b.contracted[v] = true
+}
+
+func (b *chBuilder) finish(u int, a chArc) {
+	b.final = append(b.final, chShortcut{from: u, to: a.to, weight: a.weight})
+	b.finalMiddle = append(b.finalMiddle, a.middle)
+}
+
+func removeInt(list []int, x int) []int {
+	for i, v := range list {
+		if v == x {
+			last := len(list) - 1
+			list[i] = list[last]
+			return list[:last]
+		}
+	}
+	return list
+}
+
+// neighbors returns the vertices adjacent to v in either direction, each once.
+func (b *chBuilder) neighbors(v int) []int {
+	list := make([]int, 0, len(b.out[v])+len(b.in[v]))
+	for _, a := range b.out[v] {
+		list = append(list, a.to)
+	}
+	for _, u := range b.in[v] {
+		if b.weight(v, u) == math.Inf(1) {
+			list = append(list, u)
+		}
+	}
+	return list
+}
+
+// priority is the edge difference of contracting v plus its number of
+// contracted neighbours.
+func (b *chBuilder) priority(v int) float64 {
+	removed := len(b.out[v]) + len(b.in[v])
+	return float64(len(b.shortcuts(v, prioritySettleLimit)) - removed + b.contractedNeighbors[v])
+}
+
+// shortcuts returns the shortcuts needed to contract v: for every pair of
+// neighbours u->v->w, a shortcut u->w unless a witness search from u that
+// avoids v finds a path no longer than the one through v.
+func (b *chBuilder) shortcuts(v, settleLimit int) []chShortcut {
+	var result []chShortcut
+	for _, u := range b.in[v] {
+		wuv := b.weight(u, v)
+		limit := math.Inf(-1)
+		for _, a := range b.out[v] {
+			if a.to != u && wuv+a.weight > limit {
+				limit = wuv + a.weight
+			}
+		}
+		if math.IsInf(limit, -1) {
+			continue
+		}
+
+		pending := 0
+		for _, a := range b.out[v] {
+			if a.to != u && !b.target[a.to] {
+				b.target[a.to] = true
+				pending++
+			}
+		}
+		b.witnessSearch(u, v, limit, pending, settleLimit)
+		for _, a := range b.out[v] {
+			b.target[a.to] = false
+			if a.to == u {
+				continue
+			}
+			if via := wuv + a.weight; b.dist[a.to] > via {
+				result = append(result, chShortcut{from: u, to: a.to, weight: via})
+			}
+		}
+		b.resetWitness()
+	}
+	return result
+}
+
+// witnessSearch runs a Dijkstra search from source over the remaining graph
+// without avoid. It stops once the pending marked targets are settled, at
+// limit or after settleLimit settled vertices, and leaves its tentative
+// distances in b.dist. The graph changes
+// after every contraction, so this runs on the working arcs rather than on a
+// CSRGraph and the solver.
+func (b *chBuilder) witnessSearch(source, avoid int, limit float64, pending, settleLimit int) {
+	b.dist[source] = 0
+	b.touched = append(b.touched, source)
+	pq := &dijkstraQueue[float64]{{Vertex: source}}
+	for settled := 0; pq.Len() > 0 && settled < settleLimit; {
+		item := heap.Pop(pq).(*dijkstraItem[float64])
+		u := item.Vertex
+		if item.Distance > b.dist[u] {
+			continue
+		}
+		if item.Distance > limit {
+			return
+		}
+		if b.target[u] {
+			if pending--; pending == 0 {
+				return
+			}
+		}
+		settled++
+		for _, a := range b.out[u] {
+			if a.to == avoid {
+				continue
+			}
+			if d := item.Distance + a.weight; d < b.dist[a.to] {
+				if math.IsInf(b.dist[a.to], 1) {
+					b.touched = append(b.touched, a.to)
+				}
+				b.dist[a.to] = d
+				heap.Push(pq, &dijkstraItem[float64]{Vertex: a.to, Distance: d})
+			}
+		}
+	}
+}
+
+func (b *chBuilder) resetWitness() {
+	for _, v := range b.touched {
+		b.dist[v] = math.Inf(1)
+	}
+	b.touched = b.touched[:0]
+}
+
+// hierarchy splits the final arcs into the upward and downward graphs.
+func (b *chBuilder) hierarchy(rank []int) *ContractionHierarchy {
+	n := len(b.out)
+	upOffsets := make([]int, n+1)
+	downOffsets := make([]int, n+1)
+	for _, a := range b.final {
+		if rank[a.to] > rank[a.from] {
+			upOffsets[a.from+1]++
+		} else {
+			downOffsets[a.to+1]++
+		}
+	}
+	for i := 0; i < n; i++ {
+		upOffsets[i+1] += upOffsets[i]
+		downOffsets[i+1] += downOffsets[i]
+	}
+
+	newSide := func(offsets []int) (*CSRGraph, []int, []int) {
+		m := offsets[n]
+		c := &CSRGraph{
+			Vertices: n,
+			Edges:    m,
+			Offsets:  offsets,
+			Targets:  make([]int, m),
+			Weights:  make([]float64, m),
+		}
+		return c, make([]int, m), append([]int(nil), offsets[:n]...)
+	}
+	up, upMiddle, upCursor := newSide(upOffsets)
+	down, downMiddle, downCursor := newSide(downOffsets)
+
+	for i, a := range b.final {
+		if rank[a.to] > rank[a.from] {
+			e := upCursor[a.from]
+			up.Targets[e], up.Weights[e], upMiddle[e] = a.to, a.weight, b.finalMiddle[i]
+			upCursor[a.from]++
+		} else {
+			e := downCursor[a.to]
+			down.Targets[e], down.Weights[e], downMiddle[e] = a.from, a.weight, b.finalMiddle[i]
+			downCursor[a.to]++
+		}
+	}
+	return &ContractionHierarchy{Rank: rank, Up: up, UpMiddle: upMiddle, Down: down, DownMiddle: downMiddle}
+}
+
+// Query answers a point-to-point query on the hierarchy and returns the
+// distance and the path in original vertices, with shortcuts unpacked. The
+// distance equals the one Dijkstra finds on the graph the hierarchy was built
+// from.
+func (h *ContractionHierarchy) Query(source, goal int) (float64, []int) {
+	dist, path, _ := h.QueryContext(context.Background(), source, goal)
+	return dist, path
+}
+
+// QueryContext is like Query but gives up once ctx is done and returns
+// ctx.Err().
+func (h *ContractionHierarchy) QueryContext(ctx context.Context, source, goal int) (float64, []int, error) {
+	n := len(h.Rank)
+	distF := make([]float64, n)
+	distB := make([]float64, n)
+	prev := make([]int, n)
+	next := make([]int, n)
+	for i := 0; i < n; i++ {
+		distF[i] = math.Inf(1)
+		distB[i] = math.Inf(1)
+		prev[i] = -1
+		next[i] = -1
+	}
+	distF[source] = 0
+	distB[goal] = 0
+
+	best := math.Inf(1)
+	meet := -1
+	if source == goal {
+		best, meet = 0, source
+	}
+
+	pqF := &dijkstraQueue[float64]{}
+	pqB := &dijkstraQueue[float64]{}
+	heap.Push(pqF, &dijkstraItem[float64]{Vertex: source, Distance: 0})
+	heap.Push(pqB, &dijkstraItem[float64]{Vertex: goal, Distance: 0})
+
+	// Both searches only climb in rank, so, unlike plain bidirectional
+	// Dijkstra, each side runs until its own queue minimum reaches best.
+	done := ctx.Done()
+	for {
+		select {
+		case <-done:
+			return math.Inf(1), nil, ctx.Err()
+		default:
+		}
+
+		topF := queueMin(pqF, distF)
+		topB := queueMin(pqB, distB)
+		if math.Min(topF, topB) >= best {
+			break
+		}
+
+		g, pq, dist, other, link := h.Up, pqF, distF, distB, prev
+		if topB < topF {
+			g, pq, dist, other, link = h.Down, pqB, distB, distF, next
+		}
+		u := heap.Pop(pq).(*dijkstraItem[float64]).Vertex
+		for e := g.Offsets[u]; e < g.Offsets[u+1]; e++ {
+			v := g.Targets[e]
+			newDist := dist[u] + g.Weights[e]
+			if newDist >= dist[v] {
+				continue
+			}
+			dist[v] = newDist
+			link[v] = u
+			heap.Push(pq, &dijkstraItem[float64]{Vertex: v, Distance: newDist})
+			if total := newDist + other[v]; total < best {
+				best, meet = total, v
+			}
+		}
+	}
+
+	if meet == -1 {
+		return math.Inf(1), nil, nil
+	}
+
+	hops := buildPath(prev, source, meet)
+	for v := next[meet]; v != -1; v = next[v] {
+		hops = append(hops, v)
+	}
+	path := []int{source}
+	for i := 1; i < len(hops); i++ {
+		path = h.unpack(path, hops[i-1], hops[i])
+	}
+	return best, path, nil
+}
+
+// middle returns the vertex bypassed by the hierarchy edge u->v, or -1 if it
+// is an original edge.
+func (h *ContractionHierarchy) middle(u, v int) int {
+	g, middles, from, to := h.Up, h.UpMiddle, u, v
+	if h.Rank[v] < h.Rank[u] {
+		g, middles, from, to = h.Down, h.DownMiddle, v, u
+	}
+	for e := g.Offsets[from]; e < g.Offsets[from+1]; e++ {
+		if g.Targets[e] == to {
+			return middles[e]
+		}
+	}
+	return -1
+}
+
+// unpack appends the original vertices after u on the hierarchy edge u->v to
+// path. A shortcut u->v bypassing m expands to u->m followed by m->v; an
+// explicit stack keeps deep shortcut chains off the call stack.
+func (h *ContractionHierarchy) unpack(path []int, u, v int) []int {
+	stack := [][2]int{{u, v}}
+	for len(stack) > 0 {
+		arc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		m := h.middle(arc[0], arc[1])
+		if m < 0 {
+			path = append(path, arc[1])
+			continue
+		}
+		stack = append(stack, [2]int{m, arc[1]}, [2]int{arc[0], m})
+	}
+	return path
+}
+
+// Hierarchy file layout: the 8-byte magic, then little-endian uint32 version,
+// uint32 padding, uint64 vertex count, uint64 upward and downward edge counts
+// and the CRC-64/ECMA checksum of the payload. The payload is a sequence of
+// 8-byte values: the ranks, then for Up and then Down the offsets, targets,
+// weights and middle vertices.
+const (
+	hierarchyMagic      = "BMSSPCHR"
+	hierarchyVersion    = 1
+	hierarchyHeaderSize = 48
+)
+
+// WriteTo writes the hierarchy in a versioned, checksummed binary format that
+// ReadContractionHierarchy reads back.
+func (h *ContractionHierarchy) WriteTo(w io.Writer) (int64, error) {
+	hash := crc64.New(snapshotTable)
+	writePayload := func(out io.Writer) (int64, error) {
+		bw := bufio.NewWriter(out)
+		buf := make([]byte, 8)
+		var written int64
+		put := func(bits uint64) {
+			binary.LittleEndian.PutUint64(buf, bits)
+			k, _ := bw.Write(buf)
+			written += int64(k)
+		}
+		putInts := func(values []int) {
+			for _, v := range values {
+				put(uint64(v))
+			}
+		}
+		putInts(h.Rank)
+		for _, side := range []struct {
+			g      *CSRGraph
+			middle []int
+		}{{h.Up, h.UpMiddle}, {h.Down, h.DownMiddle}} {
+			putInts(side.g.Offsets)
+			putInts(side.g.Targets)
+			for _, weight := range side.g.Weights {
+				put(math.Float64bits(weight))
+			}
+			putInts(side.middle)
+		}
+		return written, bw.Flush()
+	}
+	if _, err := writePayload(hash); err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, hierarchyHeaderSize)
+	copy(header, hierarchyMagic)
+	binary.LittleEndian.PutUint32(header[8:], hierarchyVersion)
+	binary.LittleEndian.PutUint64(header[16:], uint64(len(h.Rank)))
+	binary.LittleEndian.PutUint64(header[24:], uint64(h.Up.Edges))
+	binary.LittleEndian.PutUint64(header[32:], uint64(h.Down.Edges))
+	binary.LittleEndian.PutUint64(header[40:], hash.Sum64())
+	k, err := w.Write(header)
+	if err != nil {
+		return int64(k), err
+	}
+	written, err := writePayload(w)
+	return int64(k) + written, err
+}
+
+// ReadContractionHierarchy reads a hierarchy written by
+// ContractionHierarchy.WriteTo and verifies its checksum. Malformed input is
+// reported with ErrSnapshotFormat.
+func ReadContractionHierarchy(r io.Reader) (*ContractionHierarchy, error) {
+	header := make([]byte, hierarchyHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: reading header: %v", ErrSnapshotFormat, err)
+	}
+	if string(header[:8]) != hierarchyMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrSnapshotFormat)
+	}
+	if version := binary.LittleEndian.Uint32(header[8:]); version != hierarchyVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrSnapshotFormat, version)
+	}
+	vertices := binary.LittleEndian.Uint64(header[16:])
+	upEdges := binary.LittleEndian.Uint64(header[24:])
+	downEdges := binary.LittleEndian.Uint64(header[32:])
+	checksum := binary.LittleEndian.Uint64(header[40:])
+
+	limit := uint64(maxInt / 8)
+	if vertices >= limit/4 || upEdges >= limit/4 || downEdges >= limit/4 {
+		return nil, fmt.Errorf("%w: payload too large for this platform", ErrSnapshotFormat)
+	}
+	words := 3*vertices + 2 + 3*upEdges + 3*downEdges
+	if words >= limit {
+		return nil, fmt.Errorf("%w: payload too large for this platform", ErrSnapshotFormat)
+	}
+	n := int(vertices)
+	payload, err := readSnapshotPayload(r, int(8*words))
+	if err != nil {
+		return nil, err
+	}
+	if crc64.Checksum(payload, snapshotTable) != checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrSnapshotFormat)
+	}
+
+	take := func(count int) []byte {
+		section := payload[:count*8]
+		payload = payload[count*8:]
+		return section
+	}
+	ints := func(count int) []int { return decodeInts(take(count), false) }
+
+	h := &ContractionHierarchy{Rank: ints(n)}
+	seen := make([]bool, n)
+	for _, r := range h.Rank {
+		if r < 0 || r >= n || seen[r] {
+			return nil, fmt.Errorf("%w: ranks are not a permutation", ErrSnapshotFormat)
+		}
+		seen[r] = true
+	}
+	// Every edge must climb in rank and every shortcut must bypass a vertex
+	// ranked below both ends; otherwise queries and unpacking could loop.
+	side := func(m int) (*CSRGraph, []int, error) {
+		c := &CSRGraph{Vertices: n, Edges: m}
+		c.Offsets = ints(n + 1)
+		c.Targets = ints(m)
+		c.Weights = decodeFloats(take(m), false)
+		middle := ints(m)
+		if err := checkSnapshotCSR(c); err != nil {
+			return nil, nil, err
+		}
+		for u := 0; u < n; u++ {
+			for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+				v, mid := c.Targets[e], middle[e]
+				if h.Rank[v] <= h.Rank[u] {
+					return nil, nil, fmt.Errorf("%w: edge %d->%d does not climb in rank", ErrSnapshotFormat, u, v)
+				}
+				if mid != -1 && (mid < 0 || mid >= n || h.Rank[mid] >= h.Rank[u]) {
+					return nil, nil, fmt.Errorf("%w: invalid shortcut vertex %d", ErrSnapshotFormat, mid)
+				}
+			}
+		}
+		return c, middle, nil
+	}
+	if h.Up, h.UpMiddle, err = side(int(upEdges)); err != nil {
+		return nil, err
+	}
+	if h.Down, h.DownMiddle, err = side(int(downEdges)); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
@@ -0,0 +1,98 @@
+package bmssp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestContractionHierarchy(t *testing.T) {
+	rng := rand.New(rand.NewSource(171))
+	for iter := 0; iter < 20; iter++ {
+		n := 10 + rng.Intn(60)
+		g := makeSparseGraph(n, 3*n, rng.Int63())
+		if iter%4 == 0 {
+			// Parallel edges, self-loops and zero weights.
+			u := rng.Intn(n)
+			g.AddEdge(u, u, 1)
+			g.AddEdge(u, (u+1)%n, 0)
+			g.AddEdge(u, (u+1)%n, 5)
+		}
+		h := NewContractionHierarchy(g)
+		for source := 0; source < n; source++ {
+			goal := rng.Intn(n)
+			want, _ := Dijkstra(g, source, goal)
+			got, path := h.Query(source, goal)
+			if math.IsInf(want, 1) {
+				if !math.IsInf(got, 1) || path != nil {
+					t.Fatalf("expected no path %d->%d, got %f %v", source, goal, got, path)
+				}
+				continue
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("distance mismatch %d->%d: %f vs %f", source, goal, got, want)
+			}
+			assertValidPath(t, g, source, goal, got, path)
+		}
+	}
+}
+
+func TestContractionHierarchy_Ranks(t *testing.T) {
+	g := makeSparseGraph(40, 120, 173)
+	h := NewContractionHierarchy(g)
+	seen := make([]bool, g.Vertices)
+	for _, r := range h.Rank {
+		if r < 0 || r >= g.Vertices || seen[r] {
+			t.Fatalf("ranks are not a permutation: %v", h.Rank)
+		}
+		seen[r] = true
+	}
+	for _, side := range []*CSRGraph{h.Up, h.Down} {
+		for u := 0; u < side.Vertices; u++ {
+			for e := side.Offsets[u]; e < side.Offsets[u+1]; e++ {
+				if h.Rank[side.Targets[e]] <= h.Rank[u] {
+					t.Fatalf("edge %d->%d does not climb in rank", u, side.Targets[e])
+				}
+			}
+		}
+	}
+}
+
+func TestContractionHierarchy_RoundTrip(t *testing.T) {
+	g := makeSparseGraph(30, 90, 177)
+	h := NewContractionHierarchy(g)
+	var buf bytes.Buffer
+	if _, err := h.WriteTo(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	data := buf.Bytes()
+	got, err := ReadContractionHierarchy(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !reflect.DeepEqual(got, h) {
+		t.Fatalf("round trip mismatch")
+	}
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[hierarchyHeaderSize] ^= 0xff
+	if _, err := ReadContractionHierarchy(bytes.NewReader(corrupt)); !errors.Is(err, ErrSnapshotFormat) {
+		t.Fatalf("expected ErrSnapshotFormat, got %v", err)
+	}
+
+	// Header counts whose payload would overflow or exceed the input must be
+	// rejected without allocating it.
+	for _, counts := range [][3]uint64{{1 << 57, 0, 0}, {1 << 57, 1 << 57, 1 << 57}, {1 << 28, 1 << 28, 0}} {
+		huge := append([]byte(nil), data...)
+		for i, c := range counts {
+			binary.LittleEndian.PutUint64(huge[16+8*i:], c)
+		}
+		if _, err := ReadContractionHierarchy(bytes.NewReader(huge)); !errors.Is(err, ErrSnapshotFormat) {
+			t.Fatalf("expected ErrSnapshotFormat for counts %v, got %v", counts, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package bmssp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSolveContext_Canceled(t *testing.T) {
+	g := makeSparseGraph(200, 800, 41)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, force := range []bool{false, true} {
+		solver := NewSolver(g)
+		solver.ForceBMSSP = force
+		if _, _, err := solver.SolveContext(ctx, 0, 199); !errors.Is(err, context.Canceled) {
+			t.Fatalf("force=%v: expected context.Canceled, got %v", force, err)
+		}
+		if _, err := solver.SolveAllContext(ctx, 0); !errors.Is(err, context.Canceled) {
+			t.Fatalf("force=%v: expected context.Canceled from SolveAllContext, got %v", force, err)
+		}
+
+		// The solver stays usable after a canceled query.
+		want, _ := Dijkstra(g, 0, 199)
+		got, _, err := solver.SolveContext(context.Background(), 0, 199)
+		if err != nil || got != want {
+			t.Fatalf("force=%v: expected %f after cancellation, got %f (%v)", force, want, got, err)
+		}
+	}
+}
+
+func TestSolveContext_CanceledDuringSearch(t *testing.T) {
+	g := makeSparseGraph(200, 800, 43)
+	solver := NewSolver(g)
+	solver.ForceBMSSP = true
+	// Build the transformation up front so that cancellation is observed by
+	// the BMSSP loops rather than the transformation.
+	if _, _, err := solver.transformed(context.Background()); err != nil {
+		t.Fatalf("transformation failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := solver.SolveContext(ctx, 0, 199); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestDijkstraContext_Deadline(t *testing.T) {
+	g := makeSparseGraph(100, 400, 47)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	if _, _, err := DijkstraContext(ctx, g, 0, 99); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	want, _ := Dijkstra(g, 0, 99)
+	got, _, err := DijkstraContext(context.Background(), g, 0, 99)
+	if err != nil || got != want {
+		t.Fatalf("expected %f, got %f (%v)", want, got, err)
+	}
+}
+
+func TestNewConstantDegreeGraphContext_Canceled(t *testing.T) {
+	g := makeSparseGraph(50, 200, 53)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := NewConstantDegreeGraphContext(ctx, g); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if transform, err := NewConstantDegreeGraphContext(context.Background(), g); err != nil || transform.Graph == nil {
+		t.Fatalf("expected transformation, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package bmssp
+
+import "sync"
+
+// CSRGraphOf is an immutable directed graph in compressed sparse row form. The
+// outgoing edges of vertex u are stored at indices Offsets[u] through
+// Offsets[u+1]-1 of Targets and Weights, so the whole graph lives in three flat
+// arrays regardless of its size.
+type CSRGraphOf[W Weight] struct {
+	Vertices int
+	Edges    int
+	Offsets  []int
+	Targets  []int
+	Weights  []W
+
+	// reverse caches the result of Reverse. It is held through a pointer so
+	// that graphs can be copied; copies share it, since neither can change.
+	reverse *csrReverse[W]
+}
+
+type csrReverse[W Weight] struct {
+	once  sync.Once
+	graph *CSRGraphOf[W]
+}
+
+// CSRGraph is the float64-weighted form of CSRGraphOf.
+type CSRGraph = CSRGraphOf[float64]
+
+// NewCSRGraph freezes g into compressed sparse row form. The edges of each
+// vertex keep the order of g.Adj, so the edge at Offsets[u]+i corresponds to
+// g.Adj[u][i].
+func NewCSRGraph[W Weight](g *GraphOf[W]) *CSRGraphOf[W] {
+	n := g.Vertices
+	offsets := make([]int, n+1)
+	for u, edges := range g.Adj {
+		offsets[u+1] = offsets[u] + len(edges)
+	}
+	m := offsets[n]
+	targets := make([]int, m)
+	weights := make([]W, m)
+	for u, edges := range g.Adj {
+		base := offsets[u]
+		for i, edge := range edges {
+			targets[base+i] = edge.To
+			weights[base+i] = edge.Weight
+		}
+	}
+	return &CSRGraphOf[W]{
+		Vertices: n,
+		Edges:    m,
+		Offsets:  offsets,
+		Targets:  targets,
+		Weights:  weights,
+	}
+}
+
+// Validate checks every edge of the graph and returns the first problem found.
+func (c *CSRGraphOf[W]) Validate() error {
+	for u := 0; u < c.Vertices; u++ {
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			if err := checkEdge(u, c.Targets[e], c.Weights[e], c.Vertices); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Degree returns the number of outgoing edges of u.
+func (c *CSRGraphOf[W]) Degree(u int) int {
+	return c.Offsets[u+1] - c.Offsets[u]
+}
+
+// ToGraph returns a mutable copy of the graph.
+func (c *CSRGraphOf[W]) ToGraph() *GraphOf[W] {
+	g := NewGraphOf[W](c.Vertices)
+	for u := 0; u < c.Vertices; u++ {
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			g.AddEdge(u, c.Targets[e], c.Weights[e])
+		}
+	}
+	return g
+}
+
+// Reverse returns the graph with every edge reversed: the edges of vertex v in
+// the result are the edges into v. The reverse is built on first use and
+// cached, so backward searches over the same graph share it.
+func (c *CSRGraphOf[W]) Reverse() *CSRGraphOf[W] {
+	lazyInit.Lock()
+	if c.reverse == nil {
+		c.reverse = &csrReverse[W]{}
+	}
+	r := c.reverse
+	lazyInit.Unlock()
+
+	r.once.Do(func() {
+		n := c.Vertices
+		offsets := make([]int, n+1)
+		for _, v := range c.Targets {
+			offsets[v+1]++
+		}
+		for v := 0; v < n; v++ {
+			offsets[v+1] += offsets[v]
+		}
+		targets := make([]int, c.Edges)
+		weights := make([]W, c.Edges)
+		cursor := append([]int(nil), offsets[:n]...)
+		for u := 0; u < n; u++ {
+			for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+				v := c.Targets[e]
+				targets[cursor[v]] = u
+				weights[cursor[v]] = c.Weights[e]
+				cursor[v]++
+			}
+		}
+		r.graph = &CSRGraphOf[W]{
+			Vertices: n,
+			Edges:    c.Edges,
+			Offsets:  offsets,
+			Targets:  targets,
+			Weights:  weights,
+		}
+	})
+	return r.graph
+}
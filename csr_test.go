@@ -0,0 +1,143 @@
+package bmssp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestCSRGraph_RoundTrip(t *testing.T) {
+	g := makeSparseGraph(40, 160, 8)
+	c := NewCSRGraph(g)
+
+	if c.Vertices != g.Vertices || c.Edges != g.Edges {
+		t.Fatalf("size mismatch: csr=(%d,%d) graph=(%d,%d)", c.Vertices, c.Edges, g.Vertices, g.Edges)
+	}
+	for u, edges := range g.Adj {
+		if c.Degree(u) != len(edges) {
+			t.Fatalf("degree mismatch at %d: %d vs %d", u, c.Degree(u), len(edges))
+		}
+		for i, edge := range edges {
+			e := c.Offsets[u] + i
+			if c.Targets[e] != edge.To || c.Weights[e] != edge.Weight {
+				t.Fatalf("edge %d of vertex %d mismatch", i, u)
+			}
+		}
+	}
+
+	back := c.ToGraph()
+	for u := range g.Adj {
+		if len(back.Adj[u]) != len(g.Adj[u]) {
+			t.Fatalf("round trip degree mismatch at %d", u)
+		}
+		for i := range g.Adj[u] {
+			if back.Adj[u][i] != g.Adj[u][i] {
+				t.Fatalf("round trip edge mismatch at %d/%d", u, i)
+			}
+		}
+	}
+}
+
+func TestGraph_CSRCache(t *testing.T) {
+	g := NewGraph(3)
+	g.AddEdge(0, 1, 1)
+
+	first := g.CSR()
+	if g.CSR() != first {
+		t.Fatalf("expected cached CSR to be reused")
+	}
+	g.AddEdge(1, 2, 1)
+	second := g.CSR()
+	if second == first || second.Edges != 2 {
+		t.Fatalf("expected CSR to be rebuilt after AddEdge, got %d edges", second.Edges)
+	}
+}
+
+func TestGraph_CSRCacheCopies(t *testing.T) {
+	g := NewGraph(3)
+	g.AddEdge(0, 1, 1)
+	first := g.CSR()
+
+	// A copy made after the cache was filled must not see the edges of the
+	// original, nor the original those of the copy.
+	h := *g
+	h.Adj = append([][]Edge(nil), g.Adj...)
+	h.AddEdge(1, 2, 1)
+	g.AddEdge(2, 0, 1)
+	if c := h.CSR(); c.Edges != 2 || c.Targets[1] != 2 {
+		t.Fatalf("copy sees the wrong edges: %+v", c)
+	}
+	if c := g.CSR(); c == first || c.Edges != 2 || c.Targets[1] != 0 {
+		t.Fatalf("original sees the wrong edges: %+v", c)
+	}
+
+	reverse := g.CSR().Reverse()
+	c := *g.CSR()
+	if c.Reverse() != reverse {
+		t.Fatalf("expected copies of a CSRGraph to share its reverse")
+	}
+}
+
+func TestDijkstra_DirectAdjEdits(t *testing.T) {
+	g := NewGraph(3)
+	g.AddEdge(0, 1, 1)
+	if d, _ := Dijkstra(g, 0, 2); !math.IsInf(d, 1) {
+		t.Fatalf("expected no path, got %f", d)
+	}
+	g.Adj[1] = append(g.Adj[1], Edge{To: 2, Weight: 2})
+	g.Edges++
+	if d, path := Dijkstra(g, 0, 2); d != 3 || len(path) != 3 {
+		t.Fatalf("Dijkstra ignores direct edits of Adj: %f %v", d, path)
+	}
+}
+
+func TestCSRSolver(t *testing.T) {
+	rng := rand.New(rand.NewSource(55))
+	for iter := 0; iter < 15; iter++ {
+		n := 15 + rng.Intn(15)
+		g := makeSparseGraph(n, 4*n, rng.Int63())
+		c := NewCSRGraph(g)
+
+		for _, force := range []bool{false, true} {
+			solver := NewCSRSolver(c)
+			solver.ForceBMSSP = force
+			for trial := 0; trial < 5; trial++ {
+				source := rng.Intn(n)
+				target := rng.Intn(n)
+				want, _ := Dijkstra(g, source, target)
+				got, path := solver.Solve(source, target)
+				if math.IsInf(want, 1) {
+					if !math.IsInf(got, 1) || path != nil {
+						t.Fatalf("expected no path, got dist=%f path=%v", got, path)
+					}
+					continue
+				}
+				if math.Abs(got-want) > 1e-9 {
+					t.Fatalf("distance mismatch: csr=%f dijkstra=%f", got, want)
+				}
+				assertValidPath(t, g, source, target, got, path)
+			}
+		}
+	}
+}
+
+func TestConstantDegreeCSR(t *testing.T) {
+	g := makeSparseGraph(30, 90, 12)
+	fromGraph := NewConstantDegreeGraph(g)
+	fromCSR := NewConstantDegreeCSR(NewCSRGraph(g))
+
+	if fromCSR.Graph != nil {
+		t.Fatalf("expected no adjacency-list graph for CSR input")
+	}
+	if fromCSR.CSR.Vertices != fromGraph.Graph.Vertices || fromCSR.CSR.Edges != fromGraph.Graph.Edges {
+		t.Fatalf("transformed size mismatch")
+	}
+	for trial := 0; trial < 10; trial++ {
+		source, target := trial, 29-trial
+		want, _ := DijkstraCSR(fromGraph.CSR, fromGraph.OrigToNew[source], fromGraph.OrigToNew[target])
+		got, _ := DijkstraCSR(fromCSR.CSR, fromCSR.OrigToNew[source], fromCSR.OrigToNew[target])
+		if want != got && !(math.IsInf(want, 1) && math.IsInf(got, 1)) {
+			t.Fatalf("distance mismatch: %f vs %f", got, want)
+		}
+	}
+}
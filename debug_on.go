@@ -0,0 +1,7 @@
+//go:build bmsspdebug
+
+package bmssp
+
+// debugChecks enables expensive internal assertions. Build with the
+// bmsspdebug tag to turn them on.
+const debugChecks = true
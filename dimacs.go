@@ -0,0 +1,221 @@
+package bmssp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrDIMACSFormat is returned when a DIMACS file is malformed.
+var ErrDIMACSFormat = errors.New("bmssp: invalid DIMACS input")
+
+// Query is a point-to-point shortest-path query.
+type Query struct {
+	Source int
+	Goal   int
+}
+
+// DIMACSQueries holds the contents of a 9th DIMACS Challenge query file. Kind is
+// "ss" for single-source files, which fill Sources, or "p2p" for
+// point-to-point files, which fill Pairs. Count is the number of queries
+// declared on the problem line. Vertex ids are 0-based.
+type DIMACSQueries struct {
+	Kind    string
+	Count   int
+	Sources []int
+	Pairs   []Query
+}
+
+// maxDIMACSVertices bounds the vertex count ReadDIMACSGraph accepts from a
+// problem line. It is an order of magnitude above the largest Challenge
+// instance, the full USA road network with about 24 million vertices.
+const maxDIMACSVertices = 1 << 28
+
+// ReadDIMACSGraph reads a graph in the 9th DIMACS Challenge ".gr" format
+// ("p sp n m" followed by m "a u v w" arc lines). Vertex ids are converted from
+// 1-based to 0-based. Arcs are validated as with TryAddEdge. The graph grows
+// with the arcs read and only takes its declared size once the whole input
+// has been checked, so a problem line that overstates the graph cannot make
+// it allocate for vertices the input never delivers.
+func ReadDIMACSGraph(r io.Reader) (*Graph, error) {
+	var g *Graph
+	vertices, arcs := 0, 0
+	err := scanDIMACS(r, func(line int, fields []string) error {
+		switch fields[0] {
+		case "p":
+			if g != nil {
+				return dimacsError(line, "duplicate problem line")
+			}
+			if len(fields) != 4 || fields[1] != "sp" {
+				return dimacsError(line, "expected \"p sp <vertices> <arcs>\"")
+			}
+			n, err1 := strconv.Atoi(fields[2])
+			m, err2 := strconv.Atoi(fields[3])
+			if err1 != nil || err2 != nil || m < 0 {
+				return dimacsError(line, "invalid problem size")
+			}
+			if n < 0 {
+				return fmt.Errorf("%w: %d", ErrNegativeVertexCount, n)
+			}
+			if n > maxDIMACSVertices {
+				return dimacsError(line, "%d vertices exceed the supported maximum of %d", n, maxDIMACSVertices)
+			}
+			g = NewGraph(0)
+			vertices, arcs = n, m
+		case "a":
+			if g == nil {
+				return dimacsError(line, "arc before problem line")
+			}
+			if len(fields) != 4 {
+				return dimacsError(line, "expected \"a <from> <to> <weight>\"")
+			}
+			u, err1 := strconv.Atoi(fields[1])
+			v, err2 := strconv.Atoi(fields[2])
+			w, err3 := strconv.ParseFloat(fields[3], 64)
+			if err1 != nil || err2 != nil || err3 != nil {
+				return dimacsError(line, "invalid arc")
+			}
+			if err := checkEdge(u-1, v-1, w, vertices); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+			growDIMACSGraph(g, max(u, v))
+			g.AddEdge(u-1, v-1, w)
+		default:
+			return dimacsError(line, "unknown line type %q", fields[0])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, fmt.Errorf("%w: missing problem line", ErrDIMACSFormat)
+	}
+	if g.Edges != arcs {
+		return nil, fmt.Errorf("%w: problem line declares %d arcs, found %d", ErrDIMACSFormat, arcs, g.Edges)
+	}
+	growDIMACSGraph(g, vertices)
+	return g, nil
+}
+
+// growDIMACSGraph adds isolated vertices to g until it has n of them.
+func growDIMACSGraph(g *Graph, n int) {
+	if n > g.Vertices {
+		g.Adj = slices.Grow(g.Adj, n-g.Vertices)[:n]
+		g.Vertices = n
+	}
+}
+
+// WriteDIMACSGraph writes g in the 9th DIMACS Challenge ".gr" format with
+// 1-based vertex ids. Integral weights are written as integers, as the format
+// expects; other weights are written in decimal notation, which
+// ReadDIMACSGraph reads back exactly.
+func WriteDIMACSGraph(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "p sp %d %d\n", g.Vertices, g.Edges)
+	for u, edges := range g.Adj {
+		for _, edge := range edges {
+			fmt.Fprintf(bw, "a %d %d %s\n", u+1, edge.To+1, formatDIMACSWeight(edge.Weight))
+		}
+	}
+	return bw.Flush()
+}
+
+// formatDIMACSWeight formats w without an exponent.
+func formatDIMACSWeight(w float64) string {
+	if w == math.Trunc(w) && math.Abs(w) < 1<<53 {
+		return strconv.FormatInt(int64(w), 10)
+	}
+	return strconv.FormatFloat(w, 'f', -1, 64)
+}
+
+// ReadDIMACSQueries reads a 9th DIMACS Challenge query file: either ".ss"
+// ("p aux sp ss n" followed by "s v" lines) or ".p2p" ("p aux sp p2p n"
+// followed by "q s t" lines). Vertex ids are converted to 0-based; they are
+// not checked against a graph, since query files do not name one. The number
+// of queries must match the count on the problem line.
+func ReadDIMACSQueries(r io.Reader) (*DIMACSQueries, error) {
+	var q *DIMACSQueries
+	err := scanDIMACS(r, func(line int, fields []string) error {
+		switch fields[0] {
+		case "p":
+			if q != nil {
+				return dimacsError(line, "duplicate problem line")
+			}
+			if len(fields) != 5 || fields[1] != "aux" || fields[2] != "sp" || (fields[3] != "ss" && fields[3] != "p2p") {
+				return dimacsError(line, "expected \"p aux sp ss|p2p <count>\"")
+			}
+			count, err := strconv.Atoi(fields[4])
+			if err != nil || count < 0 {
+				return dimacsError(line, "invalid query count")
+			}
+			q = &DIMACSQueries{Kind: fields[3], Count: count}
+		case "s":
+			if q == nil || q.Kind != "ss" {
+				return dimacsError(line, "source line outside of an ss file")
+			}
+			if len(fields) != 2 {
+				return dimacsError(line, "expected \"s <vertex>\"")
+			}
+			v, err := strconv.Atoi(fields[1])
+			if err != nil || v < 1 {
+				return dimacsError(line, "invalid source")
+			}
+			q.Sources = append(q.Sources, v-1)
+		case "q":
+			if q == nil || q.Kind != "p2p" {
+				return dimacsError(line, "query line outside of a p2p file")
+			}
+			if len(fields) != 3 {
+				return dimacsError(line, "expected \"q <source> <target>\"")
+			}
+			s, err1 := strconv.Atoi(fields[1])
+			t, err2 := strconv.Atoi(fields[2])
+			if err1 != nil || err2 != nil || s < 1 || t < 1 {
+				return dimacsError(line, "invalid query")
+			}
+			q.Pairs = append(q.Pairs, Query{Source: s - 1, Goal: t - 1})
+		default:
+			return dimacsError(line, "unknown line type %q", fields[0])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if q == nil {
+		return nil, fmt.Errorf("%w: missing problem line", ErrDIMACSFormat)
+	}
+	if found := len(q.Sources) + len(q.Pairs); found != q.Count {
+		return nil, fmt.Errorf("%w: problem line declares %d queries, found %d", ErrDIMACSFormat, q.Count, found)
+	}
+	return q, nil
+}
+
+// scanDIMACS calls fn with the 1-based line number and the fields of every
+// non-empty, non-comment line of r.
+func scanDIMACS(r io.Reader, fn func(line int, fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+		if err := fn(line, fields); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func dimacsError(line int, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: line %d: %s", ErrDIMACSFormat, line, fmt.Sprintf(format, args...))
+}
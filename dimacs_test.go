@@ -0,0 +1,144 @@
+package bmssp
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const sampleDIMACSGraph = `c 9th DIMACS Implementation Challenge sample
+c
+p sp 5 6
+a 1 2 4
+a 1 3 2
+a 2 3 5
+a 2 4 10
+a 3 4 3
+a 4 5 1
+`
+
+func TestReadDIMACSGraph(t *testing.T) {
+	g, err := ReadDIMACSGraph(strings.NewReader(sampleDIMACSGraph))
+	if err != nil {
+		t.Fatalf("ReadDIMACSGraph failed: %v", err)
+	}
+	if g.Vertices != 5 || g.Edges != 6 {
+		t.Fatalf("expected 5 vertices and 6 edges, got %d and %d", g.Vertices, g.Edges)
+	}
+
+	dist, path := NewSolver(g).Solve(0, 4)
+	if dist != 6 {
+		t.Fatalf("expected distance 6, got %f", dist)
+	}
+	assertValidPath(t, g, 0, 4, dist, path)
+}
+
+func TestReadDIMACSGraph_Errors(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"missing problem", "a 1 2 3\n", ErrDIMACSFormat},
+		{"no problem line", "c only comments\n", ErrDIMACSFormat},
+		{"bad problem", "p max 3 1\n", ErrDIMACSFormat},
+		{"arc count", "p sp 2 2\na 1 2 1\n", ErrDIMACSFormat},
+		{"malformed arc", "p sp 2 1\na 1 x 1\n", ErrDIMACSFormat},
+		{"vertex range", "p sp 2 1\na 1 3 1\n", ErrVertexOutOfRange},
+		{"zero vertex", "p sp 2 1\na 0 1 1\n", ErrVertexOutOfRange},
+		{"negative weight", "p sp 2 1\na 1 2 -1\n", ErrNegativeWeight},
+		{"negative vertices", "p sp -1 0\n", ErrNegativeVertexCount},
+		{"too many vertices", "p sp 1000000000000 0\n", ErrDIMACSFormat},
+	}
+	for _, tc := range cases {
+		if _, err := ReadDIMACSGraph(strings.NewReader(tc.input)); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestReadDIMACSGraph_OverstatedSize(t *testing.T) {
+	// A truncated file must fail before the declared vertices are allocated.
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := ReadDIMACSGraph(strings.NewReader("p sp 200000000 2\na 1 2 1\n"))
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, ErrDIMACSFormat) {
+		t.Fatalf("expected ErrDIMACSFormat, got %v", err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Fatalf("allocated %d bytes for a two-vertex input", allocated)
+	}
+
+	// Trailing isolated vertices are still added once the input checks out.
+	g, err := ReadDIMACSGraph(strings.NewReader("p sp 4 1\na 1 2 1\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g.Vertices != 4 || len(g.Adj) != 4 || g.Edges != 1 {
+		t.Fatalf("unexpected graph: %d vertices, %d edges", g.Vertices, g.Edges)
+	}
+}
+
+func TestWriteDIMACSGraph_RoundTrip(t *testing.T) {
+	g := makeSparseGraph(30, 120, 17)
+	g.AddEdge(0, 1, 1000000)
+	g.AddEdge(1, 2, 1e20)
+	g.AddEdge(2, 3, 2.5e-7)
+	var buf bytes.Buffer
+	if err := WriteDIMACSGraph(&buf, g); err != nil {
+		t.Fatalf("WriteDIMACSGraph failed: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "a 1 2 1000000\n") {
+		t.Fatalf("integral weight not written as an integer:\n%s", buf.String())
+	}
+	if strings.ContainsAny(buf.String(), "eE") {
+		t.Fatalf("weights written with an exponent:\n%s", buf.String())
+	}
+
+	back, err := ReadDIMACSGraph(&buf)
+	if err != nil {
+		t.Fatalf("ReadDIMACSGraph failed: %v", err)
+	}
+	if back.Vertices != g.Vertices || back.Edges != g.Edges {
+		t.Fatalf("size mismatch after round trip")
+	}
+	for u := range g.Adj {
+		for i, edge := range g.Adj[u] {
+			if got := back.Adj[u][i]; got != edge {
+				t.Fatalf("edge mismatch at %d/%d: %v vs %v", u, i, got, edge)
+			}
+		}
+	}
+}
+
+func TestReadDIMACSQueries(t *testing.T) {
+	ss, err := ReadDIMACSQueries(strings.NewReader("c sources\np aux sp ss 2\ns 1\ns 4\n"))
+	if err != nil {
+		t.Fatalf("ss: %v", err)
+	}
+	if ss.Kind != "ss" || ss.Count != 2 || len(ss.Sources) != 2 || ss.Sources[0] != 0 || ss.Sources[1] != 3 {
+		t.Fatalf("unexpected ss queries: %+v", ss)
+	}
+
+	p2p, err := ReadDIMACSQueries(strings.NewReader("p aux sp p2p 1\nq 1 5\n"))
+	if err != nil {
+		t.Fatalf("p2p: %v", err)
+	}
+	if p2p.Kind != "p2p" || len(p2p.Pairs) != 1 || p2p.Pairs[0] != (Query{Source: 0, Goal: 4}) {
+		t.Fatalf("unexpected p2p queries: %+v", p2p)
+	}
+
+	if _, err := ReadDIMACSQueries(strings.NewReader("p aux sp ss 1\nq 1 2\n")); !errors.Is(err, ErrDIMACSFormat) {
+		t.Fatalf("expected ErrDIMACSFormat for mixed file, got %v", err)
+	}
+	if _, err := ReadDIMACSQueries(strings.NewReader("p aux sp p2p 2\nq 1 2\n")); !errors.Is(err, ErrDIMACSFormat) {
+		t.Fatalf("expected ErrDIMACSFormat for missing queries, got %v", err)
+	}
+	if _, err := ReadDIMACSQueries(strings.NewReader("p aux sp ss 1\ns 1\ns 2\n")); !errors.Is(err, ErrDIMACSFormat) {
+		t.Fatalf("expected ErrDIMACSFormat for extra queries, got %v", err)
+	}
+}
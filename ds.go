@@ -2,25 +2,25 @@ package bmssp
 
 import "sort"
 
-type frontierItem struct {
+type frontierItem[W Weight] struct {
 	Vertex int
-	Label  Label
+	Label  LabelOf[W]
 }
 
-type block struct {
-	items []frontierItem
-	upper Label
+type block[W Weight] struct {
+	items []frontierItem[W]
+	upper LabelOf[W]
 	id    int
-	prev  *block
-	next  *block
+	prev  *block[W]
+	next  *block[W]
 	inD0  bool
 }
 
-func newBlock(items []frontierItem, id int, inD0 bool) *block {
+func newBlock[W Weight](items []frontierItem[W], id int, inD0 bool) *block[W] {
 	sort.Slice(items, func(i, j int) bool {
 		return items[i].Label.Less(items[j].Label)
 	})
-	b := &block{
+	b := &block[W]{
 		items: items,
 		id:    id,
 		inD0:  inD0,
@@ -29,20 +29,20 @@ func newBlock(items []frontierItem, id int, inD0 bool) *block {
 	return b
 }
 
-func (b *block) recomputeUpper() {
+func (b *block[W]) recomputeUpper() {
 	if len(b.items) == 0 {
-		b.upper = infLabel()
+		b.upper = infLabel[W]()
 		return
 	}
 	b.upper = b.items[len(b.items)-1].Label
 }
 
-type blockList struct {
-	head *block
-	tail *block
+type blockList[W Weight] struct {
+	head *block[W]
+	tail *block[W]
 }
 
-func (l *blockList) append(b *block) {
+func (l *blockList[W]) append(b *block[W]) {
 	b.prev = l.tail
 	b.next = nil
 	if l.tail != nil {
@@ -53,7 +53,7 @@ func (l *blockList) append(b *block) {
 	l.tail = b
 }
 
-func (l *blockList) insertBefore(ref *block, b *block) {
+func (l *blockList[W]) insertBefore(ref *block[W], b *block[W]) {
 	if ref == nil {
 		l.append(b)
 		return
@@ -68,7 +68,7 @@ func (l *blockList) insertBefore(ref *block, b *block) {
 	ref.prev = b
 }
 
-func (l *blockList) insertAfter(ref *block, b *block) {
+func (l *blockList[W]) insertAfter(ref *block[W], b *block[W]) {
 	if ref == nil {
 		l.append(b)
 		return
@@ -83,7 +83,7 @@ func (l *blockList) insertAfter(ref *block, b *block) {
 	ref.next = b
 }
 
-func (l *blockList) remove(b *block) {
+func (l *blockList[W]) remove(b *block[W]) {
 	if b.prev != nil {
 		b.prev.next = b.next
 	} else {
@@ -98,7 +98,7 @@ func (l *blockList) remove(b *block) {
 	b.next = nil
 }
 
-func (l *blockList) prependBlocks(blocks []*block) {
+func (l *blockList[W]) prependBlocks(blocks []*block[W]) {
 	if len(blocks) == 0 {
 		return
 	}
@@ -121,9 +121,9 @@ func (l *blockList) prependBlocks(blocks []*block) {
 	l.head = first
 }
 
-func (l *blockList) prefix(limit int) ([]*block, int) {
+func (l *blockList[W]) prefix(limit int) ([]*block[W], int) {
 	total := 0
-	blocks := make([]*block, 0)
+	blocks := make([]*block[W], 0)
 	for b := l.head; b != nil && total < limit; b = b.next {
 		blocks = append(blocks, b)
 		total += len(b.items)
@@ -131,31 +131,36 @@ func (l *blockList) prefix(limit int) ([]*block, int) {
 	return blocks, total
 }
 
-type Frontier struct {
-	bound       Label
+// FrontierOf is the partially sorted block structure of Lemma 3.3 that feeds
+// the BMSSP recursion.
+type FrontierOf[W Weight] struct {
+	bound       LabelOf[W]
 	limit       int
-	d0          blockList
-	d1          blockList
-	index       blockIndex
-	values      map[int]Label
-	locations   map[int]*block
+	d0          blockList[W]
+	d1          blockList[W]
+	index       blockIndex[W]
+	values      map[int]LabelOf[W]
+	locations   map[int]*block[W]
 	nextBlockID int
 }
 
-func NewFrontier(limit int, bound Label) *Frontier {
+// Frontier is the frontier of a solver with float64 weights.
+type Frontier = FrontierOf[float64]
+
+func NewFrontier[W Weight](limit int, bound LabelOf[W]) *FrontierOf[W] {
 	if limit < 1 {
 		limit = 1
 	}
-	return &Frontier{
+	return &FrontierOf[W]{
 		bound:     bound,
 		limit:     limit,
-		index:     newBlockIndex(),
-		values:    make(map[int]Label),
-		locations: make(map[int]*block),
+		index:     newBlockIndex[W](),
+		values:    make(map[int]LabelOf[W]),
+		locations: make(map[int]*block[W]),
 	}
 }
 
-func (f *Frontier) Insert(vertex int, label Label) {
+func (f *FrontierOf[W]) Insert(vertex int, label LabelOf[W]) {
 	if !label.Less(f.bound) {
 		return
 	}
@@ -166,10 +171,10 @@ func (f *Frontier) Insert(vertex int, label Label) {
 		f.remove(vertex)
 	}
 
-	item := frontierItem{Vertex: vertex, Label: label}
+	item := frontierItem[W]{Vertex: vertex, Label: label}
 
 	if f.d1.head == nil {
-		b := newBlock([]frontierItem{item}, f.nextID(), false)
+		b := newBlock([]frontierItem[W]{item}, f.nextID(), false)
 		f.d1.append(b)
 		f.index.Insert(b.upper, b.id, b)
 		f.values[vertex] = label
@@ -179,7 +184,7 @@ func (f *Frontier) Insert(vertex int, label Label) {
 
 	target := f.index.LowerBound(label)
 	if target == nil {
-		b := newBlock([]frontierItem{item}, f.nextID(), false)
+		b := newBlock([]frontierItem[W]{item}, f.nextID(), false)
 		f.d1.append(b)
 		f.index.Insert(b.upper, b.id, b)
 		f.values[vertex] = label
@@ -198,22 +203,32 @@ func (f *Frontier) Insert(vertex int, label Label) {
 	}
 }
 
-func (f *Frontier) BatchPrepend(items []frontierItem) {
+func (f *FrontierOf[W]) BatchPrepend(items []frontierItem[W]) {
 	if len(items) == 0 {
 		return
 	}
 
-	filtered := make([]frontierItem, 0, len(items))
+	// A vertex may appear more than once in items, for instance when it is
+	// reached over parallel edges; only its smallest label is kept.
+	filtered := make([]frontierItem[W], 0, len(items))
+	seen := make(map[int]int, len(items))
 	for _, item := range items {
 		if !item.Label.Less(f.bound) {
 			continue
 		}
+		if i, ok := seen[item.Vertex]; ok {
+			if item.Label.Less(filtered[i].Label) {
+				filtered[i].Label = item.Label
+			}
+			continue
+		}
 		if existing, ok := f.values[item.Vertex]; ok {
 			if !item.Label.Less(existing) {
 				continue
 			}
 			f.remove(item.Vertex)
 		}
+		seen[item.Vertex] = len(filtered)
 		filtered = append(filtered, item)
 	}
 	if len(filtered) == 0 {
@@ -224,13 +239,13 @@ func (f *Frontier) BatchPrepend(items []frontierItem) {
 		return filtered[i].Label.Less(filtered[j].Label)
 	})
 
-	blocks := make([]*block, 0, (len(filtered)+f.limit-1)/f.limit)
+	blocks := make([]*block[W], 0, (len(filtered)+f.limit-1)/f.limit)
 	for i := 0; i < len(filtered); i += f.limit {
 		end := i + f.limit
 		if end > len(filtered) {
 			end = len(filtered)
 		}
-		blockItems := append([]frontierItem(nil), filtered[i:end]...)
+		blockItems := append([]frontierItem[W](nil), filtered[i:end]...)
 		b := newBlock(blockItems, f.nextID(), true)
 		blocks = append(blocks, b)
 		for _, item := range blockItems {
@@ -241,7 +256,7 @@ func (f *Frontier) BatchPrepend(items []frontierItem) {
 	f.d0.prependBlocks(blocks)
 }
 
-func (f *Frontier) Pull() (Label, []int) {
+func (f *FrontierOf[W]) Pull() (LabelOf[W], []int) {
 	if f.IsEmpty() {
 		return f.bound, nil
 	}
@@ -257,7 +272,7 @@ func (f *Frontier) Pull() (Label, []int) {
 		return f.nextBound(), result
 	}
 
-	candidates := make([]frontierItem, 0, total)
+	candidates := make([]frontierItem[W], 0, total)
 	for _, b := range blocks0 {
 		candidates = append(candidates, b.items...)
 	}
@@ -279,27 +294,27 @@ func (f *Frontier) Pull() (Label, []int) {
 	return f.nextBound(), result
 }
 
-func (f *Frontier) IsEmpty() bool {
+func (f *FrontierOf[W]) IsEmpty() bool {
 	return len(f.values) == 0
 }
 
-func (f *Frontier) insertIntoBlock(b *block, item frontierItem) {
+func (f *FrontierOf[W]) insertIntoBlock(b *block[W], item frontierItem[W]) {
 	idx := sort.Search(len(b.items), func(i int) bool {
 		return !b.items[i].Label.Less(item.Label)
 	})
-	b.items = append(b.items, frontierItem{})
+	b.items = append(b.items, frontierItem[W]{})
 	copy(b.items[idx+1:], b.items[idx:])
 	b.items[idx] = item
 	b.recomputeUpper()
 }
 
-func (f *Frontier) splitBlock(b *block) {
+func (f *FrontierOf[W]) splitBlock(b *block[W]) {
 	if len(b.items) <= f.limit {
 		return
 	}
 	oldUpper := b.upper
 	mid := len(b.items) / 2
-	rightItems := append([]frontierItem(nil), b.items[mid:]...)
+	rightItems := append([]frontierItem[W](nil), b.items[mid:]...)
 	b.items = b.items[:mid]
 	b.recomputeUpper()
 	f.updateIndex(b, oldUpper)
@@ -312,7 +327,7 @@ func (f *Frontier) splitBlock(b *block) {
 	}
 }
 
-func (f *Frontier) updateIndex(b *block, oldUpper Label) {
+func (f *FrontierOf[W]) updateIndex(b *block[W], oldUpper LabelOf[W]) {
 	if b.inD0 {
 		return
 	}
@@ -323,7 +338,7 @@ func (f *Frontier) updateIndex(b *block, oldUpper Label) {
 	f.index.Insert(b.upper, b.id, b)
 }
 
-func (f *Frontier) remove(vertex int) {
+func (f *FrontierOf[W]) remove(vertex int) {
 	b, ok := f.locations[vertex]
 	if !ok {
 		return
@@ -357,7 +372,7 @@ func (f *Frontier) remove(vertex int) {
 	f.updateIndex(b, oldUpper)
 }
 
-func (f *Frontier) removePrefix(list *blockList, blocks []*block, result *[]int) {
+func (f *FrontierOf[W]) removePrefix(list *blockList[W], blocks []*block[W], result *[]int) {
 	for _, b := range blocks {
 		for _, item := range b.items {
 			delete(f.values, item.Vertex)
@@ -373,7 +388,7 @@ func (f *Frontier) removePrefix(list *blockList, blocks []*block, result *[]int)
 	}
 }
 
-func (f *Frontier) removeUpToCutoff(list *blockList, blocks []*block, cutoff Label, result *[]int) {
+func (f *FrontierOf[W]) removeUpToCutoff(list *blockList[W], blocks []*block[W], cutoff LabelOf[W], result *[]int) {
 	for _, b := range blocks {
 		if len(b.items) == 0 {
 			continue
@@ -403,7 +418,7 @@ func (f *Frontier) removeUpToCutoff(list *blockList, blocks []*block, cutoff Lab
 	}
 }
 
-func (f *Frontier) nextBound() Label {
+func (f *FrontierOf[W]) nextBound() LabelOf[W] {
 	bound := f.bound
 	if f.d0.head != nil {
 		bound = f.d0.head.items[0].Label
@@ -417,7 +432,7 @@ func (f *Frontier) nextBound() Label {
 	return bound
 }
 
-func (f *Frontier) nextID() int {
+func (f *FrontierOf[W]) nextID() int {
 	id := f.nextBlockID
 	f.nextBlockID++
 	return id
@@ -0,0 +1,245 @@
+package bmssp
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// Repair brings t up to date after the edges u->v of g changed, in the style of
+// Ramalingam and Reps: t must have been computed on g as it was before the
+// change, and g must already reflect it, e.g. through SetWeight, RemoveEdge or
+// AddEdge. Apply one Repair per changed vertex pair. Afterwards t has the same
+// distances as a fresh solve from t.Source and a valid shortest-path tree.
+//
+// If u->v now offers a shorter path to v, only the vertices whose distance
+// improves are visited. If u->v carried the tree path to v and got longer or
+// disappeared, the subtree below v is detached and re-solved from its
+// unaffected in-neighbours; the rest of the tree is untouched. To find those
+// without scanning the whole graph, the first Repair of a tree indexes the
+// in-neighbours of every vertex and the children of every tree vertex, and
+// later calls keep the index current. Weights must be non-negative. With integer weights, a vertex reachable only over paths too
+// long for W is left with an infinite distance, as a fresh solve would, and
+// ErrWeightOverflow is returned once the repair is complete.
+func (t *ShortestPathTreeOf[W]) Repair(g *GraphOf[W], u, v int) error {
+	if err := checkVertex(u, g.Vertices); err != nil {
+		return err
+	}
+	if err := checkVertex(v, g.Vertices); err != nil {
+		return err
+	}
+	if len(t.Distances) != g.Vertices {
+		return fmt.Errorf("%w: tree has %d vertices, graph has %d", ErrVertexOutOfRange, len(t.Distances), g.Vertices)
+	}
+
+	inf := infinity[W]()
+	w := inf
+	for _, edge := range g.Adj[u] {
+		if edge.To == v && edge.Weight < w {
+			w = edge.Weight
+		}
+	}
+
+	ix := t.index(g)
+	if w == inf {
+		delete(ix.in[v], u)
+	} else {
+		if ix.in[v] == nil {
+			ix.in[v] = make(map[int]struct{})
+		}
+		ix.in[v][u] = struct{}{}
+	}
+
+	dist, prev := t.Distances, t.Predecessors
+	overflowed := false
+	through, ok := inf, false
+	if dist[u] != inf && w != inf {
+		through, ok = addWeights(dist[u], w, inf)
+		overflowed = !ok
+	}
+	switch {
+	case ok && through < dist[v]:
+		dist[v] = through
+		ix.setParent(prev, v, u)
+		pq := &dijkstraQueue[W]{{Vertex: v, Distance: through}}
+		overflowed = t.settle(g, ix, pq)
+	case prev[v] == u && (!ok || through > dist[v]):
+		overflowed = t.detach(g, ix, v) || overflowed
+	}
+	if overflowed {
+		sources := []SourceOf[W]{{Vertex: t.Source}}
+		return checkOverflow(NewCSRGraph(g), sources, nil, func(x int) bool { return dist[x] == inf })
+	}
+	return nil
+}
+
+// repairIndex holds what Repair needs to visit only the affected part of a
+// tree: the in-neighbours of every vertex of the graph and the children of
+// every vertex of the tree, as doubly linked sibling lists.
+type repairIndex[W Weight] struct {
+	graph       *GraphOf[W]
+	in          []map[int]struct{}
+	firstChild  []int
+	nextSibling []int
+	prevSibling []int
+}
+
+// index returns the repair index of t for g, building it if t has none yet or
+// was last repaired against another graph.
+func (t *ShortestPathTreeOf[W]) index(g *GraphOf[W]) *repairIndex[W] {
+	if ix := t.repair; ix != nil && ix.graph == g && len(ix.in) == g.Vertices {
+		return ix
+	}
+	n := g.Vertices
+	ix := &repairIndex[W]{
+		graph:       g,
+		in:          make([]map[int]struct{}, n),
+		firstChild:  make([]int, n),
+		nextSibling: make([]int, n),
+		prevSibling: make([]int, n),
+	}
+	for u, edges := range g.Adj {
+		for _, edge := range edges {
+			if ix.in[edge.To] == nil {
+				ix.in[edge.To] = make(map[int]struct{})
+			}
+			ix.in[edge.To][u] = struct{}{}
+		}
+	}
+	for i := range ix.firstChild {
+		ix.firstChild[i] = -1
+	}
+	for x, p := range t.Predecessors {
+		if p >= 0 {
+			ix.link(x, p)
+		}
+	}
+	t.repair = ix
+	return ix
+}
+
+// setParent makes p the predecessor of x, keeping the child lists in step.
+func (ix *repairIndex[W]) setParent(prev []int, x, p int) {
+	if old := prev[x]; old >= 0 {
+		ix.unlink(x, old)
+	}
+	prev[x] = p
+	if p >= 0 {
+		ix.link(x, p)
+	}
+}
+
+func (ix *repairIndex[W]) link(x, p int) {
+	ix.prevSibling[x] = -1
+	ix.nextSibling[x] = ix.firstChild[p]
+	if c := ix.firstChild[p]; c != -1 {
+		ix.prevSibling[c] = x
+	}
+	ix.firstChild[p] = x
+}
+
+func (ix *repairIndex[W]) unlink(x, p int) {
+	if s := ix.prevSibling[x]; s != -1 {
+		ix.nextSibling[s] = ix.nextSibling[x]
+	} else {
+		ix.firstChild[p] = ix.nextSibling[x]
+	}
+	if s := ix.nextSibling[x]; s != -1 {
+		ix.prevSibling[s] = ix.prevSibling[x]
+	}
+}
+
+// detach resets the subtree rooted at root, seeds each of its vertices with
+// the best edge from the rest of the tree and runs Dijkstra over the subtree.
+// Only the subtree and the out-edges of its in-neighbours are visited. It
+// reports whether a sum that does not fit W was skipped.
+func (t *ShortestPathTreeOf[W]) detach(g *GraphOf[W], ix *repairIndex[W], root int) bool {
+	dist, prev := t.Distances, t.Predecessors
+	inf := infinity[W]()
+
+	affected := map[int]bool{root: true}
+	subtree := []int{root}
+	for i := 0; i < len(subtree); i++ {
+		for c := ix.firstChild[subtree[i]]; c != -1; c = ix.nextSibling[c] {
+			if !affected[c] {
+				affected[c] = true
+				subtree = append(subtree, c)
+			}
+		}
+	}
+	for _, x := range subtree {
+		dist[x] = inf
+		ix.setParent(prev, x, -1)
+	}
+
+	// Unaffected distances are still exact, so the best edge into the
+	// subtree from outside gives each detached vertex an upper bound. The
+	// in-neighbours are visited in increasing order so that ties resolve the
+	// same way on every run.
+	var seeds []int
+	seen := make(map[int]bool)
+	for _, x := range subtree {
+		for y := range ix.in[x] {
+			if !affected[y] && !seen[y] && dist[y] != inf {
+				seen[y] = true
+				seeds = append(seeds, y)
+			}
+		}
+	}
+	sort.Ints(seeds)
+	overflowed := false
+	for _, y := range seeds {
+		for _, edge := range g.Adj[y] {
+			x := edge.To
+			if !affected[x] {
+				continue
+			}
+			d, ok := addWeights(dist[y], edge.Weight, inf)
+			if !ok {
+				overflowed = true
+				continue
+			}
+			if d < dist[x] {
+				dist[x] = d
+				ix.setParent(prev, x, y)
+			}
+		}
+	}
+	pq := &dijkstraQueue[W]{}
+	for _, x := range subtree {
+		if dist[x] != inf {
+			pq.Push(&dijkstraItem[W]{Vertex: x, Distance: dist[x]})
+		}
+	}
+	heap.Init(pq)
+	return t.settle(g, ix, pq) || overflowed
+}
+
+// settle runs Dijkstra on the tree from the entries in pq, lowering distances
+// wherever the queued vertices lead to shorter paths. It reports whether a sum
+// that does not fit W was skipped.
+func (t *ShortestPathTreeOf[W]) settle(g *GraphOf[W], ix *repairIndex[W], pq *dijkstraQueue[W]) bool {
+	dist, prev := t.Distances, t.Predecessors
+	inf := infinity[W]()
+	overflowed := false
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(*dijkstraItem[W])
+		x := item.Vertex
+		if item.Distance > dist[x] {
+			continue
+		}
+		for _, edge := range g.Adj[x] {
+			d, ok := addWeights(item.Distance, edge.Weight, inf)
+			if !ok {
+				overflowed = true
+				continue
+			}
+			if d < dist[edge.To] {
+				dist[edge.To] = d
+				ix.setParent(prev, edge.To, x)
+				heap.Push(pq, &dijkstraItem[W]{Vertex: edge.To, Distance: d})
+			}
+		}
+	}
+	return overflowed
+}
@@ -0,0 +1,160 @@
+package bmssp
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestShortestPathTree_Repair(t *testing.T) {
+	rng := rand.New(rand.NewSource(211))
+	for iter := 0; iter < 20; iter++ {
+		n := 10 + rng.Intn(50)
+		g := makeSparseGraph(n, 3*n, rng.Int63())
+		source := rng.Intn(n)
+		tree := NewSolver(g).SolveAll(source)
+
+		for step := 0; step < 40; step++ {
+			u := rng.Intn(n)
+			var v int
+			switch op := rng.Intn(4); {
+			case op < 2 && len(g.Adj[u]) > 0:
+				// Move an existing edge up or down.
+				v = g.Adj[u][rng.Intn(len(g.Adj[u]))].To
+				g.SetWeight(u, v, rng.Float64()*10)
+			case op == 2 && len(g.Adj[u]) > 0:
+				v = g.Adj[u][rng.Intn(len(g.Adj[u]))].To
+				g.RemoveEdge(u, v)
+			default:
+				v = rng.Intn(n)
+				g.AddEdge(u, v, rng.Float64()*10)
+			}
+			if err := tree.Repair(g, u, v); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for goal := 0; goal < n; goal++ {
+				want, _ := Dijkstra(g, source, goal)
+				got := tree.DistanceTo(goal)
+				if math.IsInf(want, 1) {
+					if !math.IsInf(got, 1) || tree.PathTo(goal) != nil {
+						t.Fatalf("step %d: expected %d unreachable, got %f", step, goal, got)
+					}
+					continue
+				}
+				if math.Abs(got-want) > 1e-9 {
+					t.Fatalf("step %d: distance mismatch to %d: %f vs %f", step, goal, got, want)
+				}
+				assertValidPath(t, g, source, goal, want, tree.PathTo(goal))
+			}
+		}
+		assertRepairIndex(t, g, tree)
+	}
+}
+
+func TestShortestPathTree_RepairBatch(t *testing.T) {
+	// Several edges change before any of them is repaired; one Repair per
+	// pair afterwards must still give the distances of a fresh solve.
+	rng := rand.New(rand.NewSource(213))
+	for iter := 0; iter < 20; iter++ {
+		n := 10 + rng.Intn(30)
+		g := makeSparseGraph(n, 3*n, rng.Int63())
+		source := rng.Intn(n)
+		tree := NewSolver(g).SolveAll(source)
+		// A no-op repair builds the index before the graph changes.
+		if err := tree.Repair(g, source, source); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var changed [][2]int
+		for step := 0; step < 8; step++ {
+			u, v := rng.Intn(n), rng.Intn(n)
+			if len(g.Adj[u]) > 0 && rng.Intn(2) == 0 {
+				v = g.Adj[u][rng.Intn(len(g.Adj[u]))].To
+				g.RemoveEdge(u, v)
+			} else {
+				g.AddEdge(u, v, rng.Float64()*10)
+			}
+			changed = append(changed, [2]int{u, v})
+		}
+		for _, pair := range changed {
+			if err := tree.Repair(g, pair[0], pair[1]); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		for goal := 0; goal < n; goal++ {
+			want, _ := Dijkstra(g, source, goal)
+			if got := tree.DistanceTo(goal); got != want && math.Abs(got-want) > 1e-9 {
+				t.Fatalf("distance mismatch to %d: %f vs %f", goal, got, want)
+			}
+			if !math.IsInf(want, 1) {
+				assertValidPath(t, g, source, goal, want, tree.PathTo(goal))
+			}
+		}
+		assertRepairIndex(t, g, tree)
+	}
+}
+
+// assertRepairIndex checks that the index Repair maintains matches the graph
+// and the tree it was last repaired against.
+func assertRepairIndex(t *testing.T, g *Graph, tree *ShortestPathTree) {
+	t.Helper()
+	ix := tree.repair
+	if ix == nil {
+		t.Fatal("expected Repair to index the tree")
+	}
+	for u, edges := range g.Adj {
+		for _, edge := range edges {
+			if _, ok := ix.in[edge.To][u]; !ok {
+				t.Fatalf("edge %d->%d missing from the in-neighbour index", u, edge.To)
+			}
+		}
+	}
+	children := 0
+	for p := range ix.firstChild {
+		last := -1
+		for c := ix.firstChild[p]; c != -1; c = ix.nextSibling[c] {
+			if tree.Predecessors[c] != p || ix.prevSibling[c] != last {
+				t.Fatalf("child list of %d is out of step at %d", p, c)
+			}
+			last = c
+			children++
+		}
+	}
+	for _, p := range tree.Predecessors {
+		if p >= 0 {
+			children--
+		}
+	}
+	if children != 0 {
+		t.Fatalf("child lists and predecessors disagree by %d vertices", children)
+	}
+}
+
+func TestGraph_SetWeightRemoveEdge(t *testing.T) {
+	g := NewGraph(3)
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(0, 1, 2)
+	g.AddEdge(0, 2, 3)
+	before := g.CSR()
+
+	if !g.SetWeight(0, 1, 5) || g.SetWeight(1, 0, 5) {
+		t.Fatalf("SetWeight reported the wrong edges")
+	}
+	if g.CSR() == before {
+		t.Fatalf("expected SetWeight to invalidate the CSR snapshot")
+	}
+	if !g.RemoveEdge(0, 1) || g.RemoveEdge(0, 1) {
+		t.Fatalf("RemoveEdge reported the wrong edges")
+	}
+	if g.Edges != 1 || len(g.Adj[0]) != 1 || g.Adj[0][0].To != 2 {
+		t.Fatalf("unexpected graph after removal: %d edges, %v", g.Edges, g.Adj[0])
+	}
+
+	tree := &ShortestPathTree{Distances: make([]float64, 2)}
+	if err := tree.Repair(g, 0, 1); !errors.Is(err, ErrVertexOutOfRange) {
+		t.Fatalf("expected ErrVertexOutOfRange, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package bmssp
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNegativeVertexCount is returned when a graph is created with fewer than
+	// zero vertices.
+	ErrNegativeVertexCount = errors.New("bmssp: negative vertex count")
+	// ErrVertexOutOfRange is returned when a vertex index is not in [0, Vertices).
+	ErrVertexOutOfRange = errors.New("bmssp: vertex out of range")
+	// ErrNegativeWeight is returned for an edge weight below zero. BMSSP and
+	// Dijkstra both require non-negative weights.
+	ErrNegativeWeight = errors.New("bmssp: negative edge weight")
+	// ErrNaNWeight is returned for an edge weight that is NaN.
+	ErrNaNWeight = errors.New("bmssp: NaN edge weight")
+	// ErrNaNDistance is returned for a source offset or distance bound that is
+	// NaN.
+	ErrNaNDistance = errors.New("bmssp: NaN distance")
+	// ErrWeightOverflow is returned when a distance a query would return does
+	// not fit the integer weight type of the graph.
+	ErrWeightOverflow = errors.New("bmssp: weight overflow")
+	// ErrNegativeCycle is returned when a graph with negative weights contains
+	// a reachable cycle of negative total weight, so shortest paths are
+	// undefined. The error is a *NegativeCycleError holding the cycle.
+	ErrNegativeCycle = errors.New("bmssp: negative cycle")
+	// ErrUnknownProfile is returned when a weight profile is looked up by a
+	// name that was never added.
+	ErrUnknownProfile = errors.New("bmssp: unknown weight profile")
+	// ErrProfileSize is returned when a weight profile does not hold exactly
+	// one weight per edge.
+	ErrProfileSize = errors.New("bmssp: weight profile size mismatch")
+)
+
+func checkVertex(v, vertices int) error {
+	if v < 0 || v >= vertices {
+		return fmt.Errorf("%w: vertex=%d, vertices=%d", ErrVertexOutOfRange, v, vertices)
+	}
+	return nil
+}
+
+func checkWeight[W Weight](u, v int, weight W) error {
+	if isNaN(weight) {
+		return fmt.Errorf("%w: edge %d->%d", ErrNaNWeight, u, v)
+	}
+	if weight < 0 {
+		return fmt.Errorf("%w: edge %d->%d has weight %v", ErrNegativeWeight, u, v, weight)
+	}
+	return nil
+}
+
+func checkEdge[W Weight](u, v int, weight W, vertices int) error {
+	if err := checkVertex(u, vertices); err != nil {
+		return err
+	}
+	if err := checkVertex(v, vertices); err != nil {
+		return err
+	}
+	return checkWeight(u, v, weight)
+}
@@ -0,0 +1,95 @@
+package bmssp
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestTryNewGraph(t *testing.T) {
+	if _, err := TryNewGraph(-1); !errors.Is(err, ErrNegativeVertexCount) {
+		t.Fatalf("expected ErrNegativeVertexCount, got %v", err)
+	}
+	g, err := TryNewGraph(2)
+	if err != nil || g.Vertices != 2 {
+		t.Fatalf("expected graph with 2 vertices, got %v, %v", g, err)
+	}
+}
+
+func TestTryAddEdge(t *testing.T) {
+	g := NewGraph(3)
+	cases := []struct {
+		u, v   int
+		weight float64
+		want   error
+	}{
+		{0, 1, 1, nil},
+		{0, 3, 1, ErrVertexOutOfRange},
+		{-1, 1, 1, ErrVertexOutOfRange},
+		{0, 2, -1, ErrNegativeWeight},
+		{0, 2, math.Inf(-1), ErrNegativeWeight},
+		{0, 2, math.NaN(), ErrNaNWeight},
+	}
+	for _, tc := range cases {
+		err := g.TryAddEdge(tc.u, tc.v, tc.weight)
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("TryAddEdge(%d, %d, %f): expected %v, got %v", tc.u, tc.v, tc.weight, tc.want, err)
+		}
+	}
+	if g.Edges != 1 {
+		t.Fatalf("expected rejected edges to leave the graph unchanged, got %d edges", g.Edges)
+	}
+}
+
+func TestTryNewSolver(t *testing.T) {
+	g := NewGraph(3)
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 2, -4)
+
+	if _, err := TryNewSolver(g); !errors.Is(err, ErrNegativeWeight) {
+		t.Fatalf("expected ErrNegativeWeight, got %v", err)
+	}
+	if _, err := TryNewCSRSolver(NewCSRGraph(g)); !errors.Is(err, ErrNegativeWeight) {
+		t.Fatalf("expected ErrNegativeWeight for CSR graph, got %v", err)
+	}
+
+	g.Adj[1][0].Weight = math.NaN()
+	if _, err := TryNewSolver(g); !errors.Is(err, ErrNaNWeight) {
+		t.Fatalf("expected ErrNaNWeight, got %v", err)
+	}
+}
+
+func TestTrySolve(t *testing.T) {
+	g := NewGraph(3)
+	g.AddEdge(0, 1, 1)
+	solver, err := TryNewSolver(g)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, err := solver.TrySolve(0, 3); !errors.Is(err, ErrVertexOutOfRange) {
+		t.Fatalf("expected ErrVertexOutOfRange for goal, got %v", err)
+	}
+	if _, err := solver.TrySolveAll(-1); !errors.Is(err, ErrVertexOutOfRange) {
+		t.Fatalf("expected ErrVertexOutOfRange for source, got %v", err)
+	}
+	dist, path, err := solver.TrySolve(0, 1)
+	if err != nil || dist != 1 || len(path) != 2 {
+		t.Fatalf("unexpected result: dist=%f path=%v err=%v", dist, path, err)
+	}
+}
+
+func TestSolveGonum_NegativeWeight(t *testing.T) {
+	g := simple.NewWeightedDirectedGraph(0, 0)
+	n1 := simple.Node(1)
+	n2 := simple.Node(2)
+	g.AddNode(n1)
+	g.AddNode(n2)
+	g.SetWeightedEdge(g.NewWeightedEdge(n1, n2, -3))
+
+	if _, _, err := SolveGonum(g, 1, 2); !errors.Is(err, ErrNegativeWeight) {
+		t.Fatalf("expected ErrNegativeWeight, got %v", err)
+	}
+}
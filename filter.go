@@ -0,0 +1,188 @@
+package bmssp
+
+import (
+	"context"
+	"math/bits"
+	"sort"
+)
+
+// EdgeFilter reports whether the edge from u to v may be used by a query. e is
+// the index of the edge in the compressed form of the graph; for a Graph, the
+// edge g.Adj[u][i] has index g.CSR().Offsets[u]+i. Filters restrict a single
+// query without copying the graph, e.g. to avoid tolls or closed roads.
+type EdgeFilter func(u, v, e int) bool
+
+// Bitset is a fixed-size set of non-negative integers.
+type Bitset []uint64
+
+// NewBitset returns an empty set with room for the integers in [0, n).
+func NewBitset(n int) Bitset {
+	return make(Bitset, (n+63)/64)
+}
+
+// Set adds i to the set. It panics if i is outside the set's range.
+func (b Bitset) Set(i int) {
+	b[i/64] |= 1 << (uint(i) % 64)
+}
+
+// Clear removes i from the set. It panics if i is outside the set's range.
+func (b Bitset) Clear(i int) {
+	b[i/64] &^= 1 << (uint(i) % 64)
+}
+
+// Has reports whether i is in the set. Integers outside the set's range are
+// never in it.
+func (b Bitset) Has(i int) bool {
+	return i >= 0 && i/64 < len(b) && b[i/64]&(1<<(uint(i)%64)) != 0
+}
+
+// Len returns the number of integers in the set.
+func (b Bitset) Len() int {
+	n := 0
+	for _, word := range b {
+		n += bits.OnesCount64(word)
+	}
+	return n
+}
+
+// Mask excludes vertices and edges from a query. Edges are identified by
+// their index as described for EdgeFilter. A nil Bitset excludes nothing.
+type Mask struct {
+	Vertices Bitset
+	Edges    Bitset
+}
+
+// Allow is an EdgeFilter that rejects excluded edges and every edge into or
+// out of an excluded vertex.
+func (m *Mask) Allow(u, v, e int) bool {
+	return !m.Edges.Has(e) && !m.Vertices.Has(u) && !m.Vertices.Has(v)
+}
+
+// DijkstraFiltered is Dijkstra restricted to the edges allow accepts.
+func DijkstraFiltered[W Weight](g *GraphOf[W], source, goal int, allow EdgeFilter) (W, []int) {
+	dist, path, _ := DijkstraFilteredContext(context.Background(), g, source, goal, allow)
+	return dist, path
+}
+
+// DijkstraFilteredContext is like DijkstraFiltered but gives up once ctx is
+// done and returns ctx.Err().
+func DijkstraFilteredContext[W Weight](ctx context.Context, g *GraphOf[W], source, goal int, allow EdgeFilter) (W, []int, error) {
+	return dijkstraFiltered(ctx, g.CSR(), source, goal, allow)
+}
+
+func dijkstraFiltered[W Weight](ctx context.Context, c *CSRGraphOf[W], source, goal int, allow EdgeFilter) (W, []int, error) {
+	inf := infinity[W]()
+	dist, prev, err := dijkstraSearch(ctx, c, []SourceOf[W]{{Vertex: source}}, goal, inf, allow)
+	if err != nil {
+		return inf, nil, err
+	}
+	if dist[goal] == inf {
+		return inf, nil, nil
+	}
+	return dist[goal], buildPath(prev, source, goal), nil
+}
+
+// edgeOrigins maps the edges of a transformed graph back to the original
+// edges they stand for. The transformation keeps one edge per original vertex
+// pair at the minimum weight of its parallel edges, so a filter that rejects
+// that edge may leave a heavier one usable. sorted holds the original edge
+// indices grouped by source and ordered by target and index; the edge e of the
+// transformed graph stands for sorted[lo[e]:hi[e]], which is empty for the
+// zero-weight edges inside a vertex cycle. The origins depend on the topology
+// only, so weight profiles share them.
+type edgeOrigins struct {
+	sorted []int
+	lo, hi []int
+}
+
+// edgeOrigins returns the origins of the transformed edges, built on first use
+// from the original graph orig.
+func (t *TransformationOf[W]) edgeOrigins(orig *CSRGraphOf[W]) *edgeOrigins {
+	lazyInit.Lock()
+	if t.origins == nil {
+		t.origins = &transformOrigins{}
+	}
+	o := t.origins
+	lazyInit.Unlock()
+
+	o.once.Do(func() {
+		o.origins = newEdgeOrigins(t, orig)
+	})
+	return o.origins
+}
+
+func newEdgeOrigins[W Weight](t *TransformationOf[W], orig *CSRGraphOf[W]) *edgeOrigins {
+	sorted := make([]int, orig.Edges)
+	for e := range sorted {
+		sorted[e] = e
+	}
+	for u := 0; u < orig.Vertices; u++ {
+		group := sorted[orig.Offsets[u]:orig.Offsets[u+1]]
+		sort.Slice(group, func(i, j int) bool {
+			a, b := group[i], group[j]
+			if orig.Targets[a] != orig.Targets[b] {
+				return orig.Targets[a] < orig.Targets[b]
+			}
+			return a < b
+		})
+	}
+
+	c := t.CSR
+	o := &edgeOrigins{sorted: sorted, lo: make([]int, c.Edges), hi: make([]int, c.Edges)}
+	for a := 0; a < c.Vertices; a++ {
+		u := t.NewToOrig[a]
+		start, end := orig.Offsets[u], orig.Offsets[u+1]
+		for e := c.Offsets[a]; e < c.Offsets[a+1]; e++ {
+			v := t.NewToOrig[c.Targets[e]]
+			if v == u {
+				continue
+			}
+			lo := start + sort.Search(end-start, func(i int) bool { return orig.Targets[sorted[start+i]] >= v })
+			hi := lo
+			for hi < end && orig.Targets[sorted[hi]] == v {
+				hi++
+			}
+			o.lo[e], o.hi[e] = lo, hi
+		}
+	}
+	return o
+}
+
+// transformedFilter applies an EdgeFilter over the original graph to the
+// edges of its transformation.
+type transformedFilter[W Weight] struct {
+	allow     EdgeFilter
+	orig      *CSRGraphOf[W]
+	origins   *edgeOrigins
+	newToOrig []int
+	weights   []W
+}
+
+func newTransformedFilter[W Weight](t *TransformationOf[W], orig *CSRGraphOf[W], allow EdgeFilter) *transformedFilter[W] {
+	return &transformedFilter[W]{
+		allow:     allow,
+		orig:      orig,
+		origins:   t.edgeOrigins(orig),
+		newToOrig: t.NewToOrig,
+		weights:   t.CSR.Weights,
+	}
+}
+
+// weight returns the weight of the transformed edge e out of a: the lightest
+// original edge it stands for that the filter accepts, or false if there is
+// none. Edges inside a vertex cycle are always usable.
+func (f *transformedFilter[W]) weight(a, e int) (W, bool) {
+	lo, hi := f.origins.lo[e], f.origins.hi[e]
+	if lo == hi {
+		return f.weights[e], true
+	}
+	u := f.newToOrig[a]
+	var best W
+	found := false
+	for _, idx := range f.origins.sorted[lo:hi] {
+		if w := f.orig.Weights[idx]; (!found || w < best) && f.allow(u, f.orig.Targets[idx], idx) {
+			best, found = w, true
+		}
+	}
+	return best, found
+}
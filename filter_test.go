@@ -0,0 +1,97 @@
+package bmssp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// filteredCopy builds the graph a mask leaves behind, as a reference.
+func filteredCopy(g *Graph, allow EdgeFilter) *Graph {
+	out := NewGraph(g.Vertices)
+	e := 0
+	for u, edges := range g.Adj {
+		for _, edge := range edges {
+			if allow(u, edge.To, e) {
+				out.AddEdge(u, edge.To, edge.Weight)
+			}
+			e++
+		}
+	}
+	return out
+}
+
+func TestSolveFiltered(t *testing.T) {
+	rng := rand.New(rand.NewSource(221))
+	for iter := 0; iter < 30; iter++ {
+		n := 10 + rng.Intn(50)
+		g := makeSparseGraph(n, 3*n, rng.Int63())
+		// Parallel edges, so that excluding the lighter one leaves the
+		// heavier usable.
+		for i := 0; i < n/2; i++ {
+			u := rng.Intn(n)
+			if len(g.Adj[u]) > 0 {
+				edge := g.Adj[u][rng.Intn(len(g.Adj[u]))]
+				g.AddEdge(u, edge.To, edge.Weight+rng.Float64()*5)
+			}
+		}
+
+		mask := &Mask{Vertices: NewBitset(n), Edges: NewBitset(g.Edges)}
+		for i := 0; i < n/10; i++ {
+			mask.Vertices.Set(rng.Intn(n))
+		}
+		for i := 0; i < g.Edges/5; i++ {
+			mask.Edges.Set(rng.Intn(g.Edges))
+		}
+		reference := filteredCopy(g, mask.Allow)
+
+		solvers := []*Solver{
+			NewSolverWithOptions(g, Options{Algorithm: AlgorithmBMSSP}),
+			NewSolverWithOptions(g, Options{Algorithm: AlgorithmBMSSP, DisableTransformation: true}),
+			NewSolverWithOptions(g, Options{Algorithm: AlgorithmDijkstra}),
+		}
+		for trial := 0; trial < 10; trial++ {
+			source, goal := rng.Intn(n), rng.Intn(n)
+			want, _ := Dijkstra(reference, source, goal)
+			got, path := DijkstraFiltered(g, source, goal, mask.Allow)
+			checkFiltered(t, "Dijkstra", reference, source, goal, want, got, path)
+			for i, s := range solvers {
+				got, path := s.SolveFiltered(source, goal, mask.Allow)
+				checkFiltered(t, fmt.Sprint(s.Options), reference, source, goal, want, got, path)
+				if i == 0 {
+					// The filter must not outlive the query.
+					unfiltered, _ := Dijkstra(g, source, goal)
+					if d, _ := s.Solve(source, goal); d != unfiltered && math.Abs(d-unfiltered) > 1e-9 {
+						t.Fatalf("unfiltered query after filtered one: %f vs %f", d, unfiltered)
+					}
+				}
+			}
+		}
+	}
+}
+
+func checkFiltered(t *testing.T, name string, reference *Graph, source, goal int, want, got float64, path []int) {
+	t.Helper()
+	if math.IsInf(want, 1) {
+		if !math.IsInf(got, 1) || path != nil {
+			t.Fatalf("%s: expected no path %d->%d, got %f %v", name, source, goal, got, path)
+		}
+		return
+	}
+	if math.Abs(got-want) > 1e-9 {
+		t.Fatalf("%s: distance mismatch %d->%d: %f vs %f", name, source, goal, got, want)
+	}
+	assertValidPath(t, reference, source, goal, want, path)
+}
+
+func TestBitset(t *testing.T) {
+	b := NewBitset(130)
+	b.Set(0)
+	b.Set(64)
+	b.Set(129)
+	b.Clear(64)
+	if !b.Has(0) || b.Has(64) || !b.Has(129) || b.Has(-1) || b.Has(500) || b.Len() != 2 {
+		t.Fatalf("unexpected bitset contents: %v", b)
+	}
+}
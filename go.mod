@@ -2,4 +2,4 @@ module github.com/deicod/bmssp
 
 go 1.25.6
 
-require gonum.org/v1/gonum v0.17.0 // indirect
+require gonum.org/v1/gonum v0.17.0
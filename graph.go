@@ -2,34 +2,276 @@ package bmssp
 
 import (
 	"fmt"
+	"sync"
 )
 
-type Edge struct {
+// EdgeOf is an outgoing edge of a GraphOf.
+type EdgeOf[W Weight] struct {
 	To     int
-	Weight float64
+	Weight W
 }
 
-type Graph struct {
+// GraphOf is a directed graph in adjacency-list form with edge weights of type
+// W.
+type GraphOf[W Weight] struct {
 	Vertices int
 	Edges    int
-	Adj      [][]Edge
+	Adj      [][]EdgeOf[W]
+
+	// version is bumped by every mutating method so that structures derived
+	// from the graph, such as a Solver's cached transformation, can detect
+	// that they are stale.
+	version uint64
+
+	// removed marks the vertices dropped by RemoveVertex; it is nil until
+	// the first removal.
+	removed []bool
+
+	// frozen caches the result of CSR. It is held through a pointer so that
+	// graphs can be copied; a copy notices that it does not own the cache
+	// and starts its own.
+	frozen *csrCache[W]
+}
+
+// csrCache is the compressed form of a graph as of version.
+type csrCache[W Weight] struct {
+	mu      sync.Mutex
+	owner   *GraphOf[W]
+	version uint64
+	graph   *CSRGraphOf[W]
 }
 
+// lazyInit guards the first assignment of the cache pointers of graphs and
+// transformations, which may have been created as struct literals.
+var lazyInit sync.Mutex
+
+// Edge and Graph are the float64-weighted forms used throughout the package.
+type (
+	Edge  = EdgeOf[float64]
+	Graph = GraphOf[float64]
+)
+
 func NewGraph(vertices int) *Graph {
+	return NewGraphOf[float64](vertices)
+}
+
+// NewGraphOf is NewGraph for weights of type W.
+func NewGraphOf[W Weight](vertices int) *GraphOf[W] {
 	if vertices < 0 {
 		panic("Number of vertices cannot be negative")
 	}
-	return &Graph{
+	return &GraphOf[W]{
 		Vertices: vertices,
 		Edges:    0,
-		Adj:      make([][]Edge, vertices),
+		Adj:      make([][]EdgeOf[W], vertices),
+	}
+}
+
+// TryNewGraph is like NewGraph but returns ErrNegativeVertexCount instead of
+// panicking.
+func TryNewGraph(vertices int) (*Graph, error) {
+	return TryNewGraphOf[float64](vertices)
+}
+
+// TryNewGraphOf is TryNewGraph for weights of type W.
+func TryNewGraphOf[W Weight](vertices int) (*GraphOf[W], error) {
+	if vertices < 0 {
+		return nil, fmt.Errorf("%w: %d", ErrNegativeVertexCount, vertices)
+	}
+	return NewGraphOf[W](vertices), nil
+}
+
+func (g *GraphOf[W]) AddEdge(u, v int, weight W) {
+	g.checkEndpoints(u, v)
+	if g.removed != nil {
+		g.removed[u], g.removed[v] = false, false
+	}
+	g.Adj[u] = append(g.Adj[u], EdgeOf[W]{To: v, Weight: weight})
+	g.Edges++
+	g.version++
+}
+
+// TryAddEdge adds the edge u->v after checking that both endpoints exist and
+// that the weight is a non-negative number. Invalid edges are rejected with
+// ErrVertexOutOfRange, ErrNegativeWeight or ErrNaNWeight and leave the graph
+// unchanged.
+func (g *GraphOf[W]) TryAddEdge(u, v int, weight W) error {
+	if err := checkEdge(u, v, weight, g.Vertices); err != nil {
+		return err
 	}
+	g.AddEdge(u, v, weight)
+	return nil
 }
 
-func (g *Graph) AddEdge(u, v int, weight float64) {
+// SetWeight sets the weight of every edge u->v to weight and reports whether
+// there was such an edge. It panics if u or v is not a vertex of the graph.
+func (g *GraphOf[W]) SetWeight(u, v int, weight W) bool {
+	g.checkEndpoints(u, v)
+	found := false
+	for i := range g.Adj[u] {
+		if g.Adj[u][i].To == v {
+			g.Adj[u][i].Weight = weight
+			found = true
+		}
+	}
+	if found {
+		g.version++
+	}
+	return found
+}
+
+// RemoveEdge removes every edge u->v and reports whether there was such an
+// edge. It panics if u or v is not a vertex of the graph.
+func (g *GraphOf[W]) RemoveEdge(u, v int) bool {
+	g.checkEndpoints(u, v)
+	edges := g.Adj[u][:0]
+	for _, edge := range g.Adj[u] {
+		if edge.To != v {
+			edges = append(edges, edge)
+		}
+	}
+	removed := len(g.Adj[u]) - len(edges)
+	if removed == 0 {
+		return false
+	}
+	g.Adj[u] = edges
+	g.Edges -= removed
+	g.version++
+	return true
+}
+
+// TrySetWeight is like SetWeight but reports invalid vertices and weights with
+// ErrVertexOutOfRange, ErrNegativeWeight or ErrNaNWeight instead of panicking.
+func (g *GraphOf[W]) TrySetWeight(u, v int, weight W) (bool, error) {
+	if err := checkEdge(u, v, weight, g.Vertices); err != nil {
+		return false, err
+	}
+	return g.SetWeight(u, v, weight), nil
+}
+
+// RemoveVertex removes every edge into and out of v and marks v as removed.
+// Vertex ids stay stable: v remains a valid, isolated vertex until Compact drops
+// it, and adding an edge to it makes it a regular vertex again. It panics if v
+// is not a vertex of the graph.
+func (g *GraphOf[W]) RemoveVertex(v int) {
+	g.checkEndpoints(v, v)
+	removed := len(g.Adj[v])
+	g.Adj[v] = nil
+	for u, edges := range g.Adj {
+		kept := edges[:0]
+		for _, edge := range edges {
+			if edge.To != v {
+				kept = append(kept, edge)
+			}
+		}
+		removed += len(edges) - len(kept)
+		g.Adj[u] = kept
+	}
+	if g.removed == nil {
+		g.removed = make([]bool, g.Vertices)
+	}
+	g.removed[v] = true
+	g.Edges -= removed
+	g.version++
+}
+
+// Removed reports whether v was removed with RemoveVertex and has not been
+// revived since.
+func (g *GraphOf[W]) Removed(v int) bool {
+	return g.removed != nil && v >= 0 && v < len(g.removed) && g.removed[v]
+}
+
+// Compact drops the removed vertices and renumbers the remaining ones in
+// order, so that ids are dense again. It returns the mapping from old to new
+// ids, with -1 for removed vertices. The vertex count of solvers and pools is
+// fixed when they are created, so they must be recreated after Compact.
+func (g *GraphOf[W]) Compact() []int {
+	mapping := make([]int, g.Vertices)
+	next := 0
+	for v := range mapping {
+		if g.Removed(v) {
+			mapping[v] = -1
+			continue
+		}
+		mapping[v] = next
+		next++
+	}
+
+	adj := make([][]EdgeOf[W], next)
+	for v, edges := range g.Adj {
+		if mapping[v] < 0 {
+			continue
+		}
+		for i := range edges {
+			edges[i].To = mapping[edges[i].To]
+		}
+		adj[mapping[v]] = edges
+	}
+	g.Adj = adj
+	g.Vertices = next
+	g.removed = nil
+	g.version++
+	return mapping
+}
+
+func (g *GraphOf[W]) checkEndpoints(u, v int) {
 	if u < 0 || u >= g.Vertices || v < 0 || v >= g.Vertices {
 		panic(fmt.Sprintf("Vertex index out of bounds: u=%d, v=%d, vertices=%d", u, v, g.Vertices))
 	}
-	g.Adj[u] = append(g.Adj[u], Edge{To: v, Weight: weight})
-	g.Edges++
+}
+
+// Validate checks every edge of the graph and returns the first problem found,
+// for graphs built with AddEdge or by editing Adj directly.
+func (g *GraphOf[W]) Validate() error {
+	if len(g.Adj) != g.Vertices {
+		return fmt.Errorf("%w: graph has %d adjacency lists for %d vertices", ErrVertexOutOfRange, len(g.Adj), g.Vertices)
+	}
+	for u, edges := range g.Adj {
+		for _, edge := range edges {
+			if err := checkEdge(u, edge.To, edge.Weight, g.Vertices); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Invalidate marks every structure derived from the graph as stale. Mutations
+// made through Graph methods do this automatically; call Invalidate after
+// editing Adj directly.
+func (g *GraphOf[W]) Invalidate() {
+	g.version++
+}
+
+// CSR returns the graph in compressed sparse row form. The snapshot is built on
+// first use and cached until the graph is next modified; it must not be
+// mutated. It is safe to call CSR from several goroutines as long as the graph
+// itself is not being modified.
+//
+// The cache keeps a second copy of the graph alive for as long as the graph.
+// It only notices changes made through Graph methods: after editing Adj
+// directly, call Invalidate, or CSR and everything built on it, such as
+// Solver, keep using the old edges. Dijkstra reads Adj itself and is not
+// affected.
+func (g *GraphOf[W]) CSR() *CSRGraphOf[W] {
+	lazyInit.Lock()
+	if g.frozen == nil || g.frozen.owner != g {
+		g.frozen = &csrCache[W]{owner: g}
+	}
+	cache := g.frozen
+	lazyInit.Unlock()
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.graph == nil || cache.version != g.version {
+		cache.graph = NewCSRGraph(g)
+		cache.version = g.version
+	}
+	return cache.graph
+}
+
+// ReverseCSR returns the reverse of the graph in compressed form, cached
+// together with CSR until the graph is next modified.
+func (g *GraphOf[W]) ReverseCSR() *CSRGraphOf[W] {
+	return g.CSR().Reverse()
 }
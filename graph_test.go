@@ -0,0 +1,71 @@
+package bmssp
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGraph_RemoveVertex(t *testing.T) {
+	g := NewGraph(4)
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 2, 1)
+	g.AddEdge(2, 1, 1)
+	g.AddEdge(0, 3, 5)
+	g.AddEdge(3, 2, 1)
+	g.RemoveVertex(1)
+
+	if !g.Removed(1) || g.Removed(0) {
+		t.Fatalf("unexpected removal marks")
+	}
+	if g.Vertices != 4 || g.Edges != 2 {
+		t.Fatalf("expected 4 vertices and 2 edges, got %d and %d", g.Vertices, g.Edges)
+	}
+	if dist, path := NewSolver(g).Solve(0, 2); dist != 6 || !reflect.DeepEqual(path, []int{0, 3, 2}) {
+		t.Fatalf("expected detour 0-3-2 of length 6, got %f %v", dist, path)
+	}
+	if dist, _ := Dijkstra(g, 0, 1); !math.IsInf(dist, 1) {
+		t.Fatalf("expected removed vertex to be unreachable, got %f", dist)
+	}
+}
+
+func TestGraph_Compact(t *testing.T) {
+	g := NewGraph(5)
+	g.AddEdge(0, 2, 1)
+	g.AddEdge(2, 4, 2)
+	g.AddEdge(4, 0, 3)
+	g.AddEdge(1, 3, 1)
+	g.RemoveVertex(1)
+	g.RemoveVertex(3)
+	g.RemoveVertex(0)
+	g.AddEdge(4, 0, 3) // revives 0
+
+	mapping := g.Compact()
+	if want := []int{0, -1, 1, -1, 2}; !reflect.DeepEqual(mapping, want) {
+		t.Fatalf("mapping %v, want %v", mapping, want)
+	}
+	if g.Vertices != 3 || g.Edges != 2 {
+		t.Fatalf("expected 3 vertices and 2 edges, got %d and %d", g.Vertices, g.Edges)
+	}
+	if err := g.Validate(); err != nil {
+		t.Fatalf("compacted graph is invalid: %v", err)
+	}
+	if dist, path := NewSolver(g).Solve(1, 0); dist != 5 || !reflect.DeepEqual(path, []int{1, 2, 0}) {
+		t.Fatalf("expected 1-2-0 of length 5, got %f %v", dist, path)
+	}
+}
+
+func TestGraph_TrySetWeight(t *testing.T) {
+	g := NewGraph(2)
+	g.AddEdge(0, 1, 1)
+	if _, err := g.TrySetWeight(0, 1, -1); !errors.Is(err, ErrNegativeWeight) {
+		t.Fatalf("expected ErrNegativeWeight, got %v", err)
+	}
+	if _, err := g.TrySetWeight(0, 2, 1); !errors.Is(err, ErrVertexOutOfRange) {
+		t.Fatalf("expected ErrVertexOutOfRange, got %v", err)
+	}
+	if found, err := g.TrySetWeight(0, 1, 4); !found || err != nil || g.Adj[0][0].Weight != 4 {
+		t.Fatalf("expected weight 4, got %v %v %v", found, err, g.Adj[0])
+	}
+}
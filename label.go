@@ -1,24 +1,27 @@
 package bmssp
 
-import "math"
-
 var maxInt = int(^uint(0) >> 1)
 
-type Label struct {
-	Dist   float64
+// LabelOf orders vertices by distance, then by number of edges on the path and
+// then by vertex id, which makes every label unique.
+type LabelOf[W Weight] struct {
+	Dist   W
 	Hops   int
 	Vertex int
 }
 
-func infLabel() Label {
-	return Label{
-		Dist:   math.Inf(1),
+// Label is the label of a solver with float64 weights.
+type Label = LabelOf[float64]
+
+func infLabel[W Weight]() LabelOf[W] {
+	return LabelOf[W]{
+		Dist:   infinity[W](),
 		Hops:   maxInt,
 		Vertex: maxInt,
 	}
 }
 
-func (a Label) Less(b Label) bool {
+func (a LabelOf[W]) Less(b LabelOf[W]) bool {
 	if a.Dist != b.Dist {
 		return a.Dist < b.Dist
 	}
@@ -28,10 +31,20 @@ func (a Label) Less(b Label) bool {
 	return a.Vertex < b.Vertex
 }
 
-func (a Label) Equal(b Label) bool {
+func (a LabelOf[W]) Equal(b LabelOf[W]) bool {
 	return a.Dist == b.Dist && a.Hops == b.Hops && a.Vertex == b.Vertex
 }
 
-func (a Label) LessOrEqual(b Label) bool {
+func (a LabelOf[W]) LessOrEqual(b LabelOf[W]) bool {
 	return a.Less(b) || a.Equal(b)
 }
+
+// distanceBound returns a label that every label with a distance below d
+// precedes and no label with a distance of d or more does.
+func distanceBound[W Weight](d W) LabelOf[W] {
+	return LabelOf[W]{
+		Dist:   d,
+		Hops:   -1,
+		Vertex: -1,
+	}
+}
@@ -1,14 +1,14 @@
 package bmssp
 
-type labelHeap []frontierItem
+type labelHeap[W Weight] []frontierItem[W]
 
-func (h labelHeap) Len() int           { return len(h) }
-func (h labelHeap) Less(i, j int) bool { return h[i].Label.Less(h[j].Label) }
-func (h labelHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
-func (h *labelHeap) Push(x interface{}) {
-	*h = append(*h, x.(frontierItem))
+func (h labelHeap[W]) Len() int           { return len(h) }
+func (h labelHeap[W]) Less(i, j int) bool { return h[i].Label.Less(h[j].Label) }
+func (h labelHeap[W]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *labelHeap[W]) Push(x interface{}) {
+	*h = append(*h, x.(frontierItem[W]))
 }
-func (h *labelHeap) Pop() interface{} {
+func (h *labelHeap[W]) Pop() interface{} {
 	old := *h
 	n := len(old)
 	item := old[n-1]
@@ -0,0 +1,111 @@
+package bmssp
+
+import (
+	"context"
+	"fmt"
+)
+
+// DistanceMatrixOf holds shortest distances from every origin to every
+// destination. Distances is stored row-major: the distance from Origins[i] to
+// Destinations[j] is Distances[i*len(Destinations)+j]. Trees holds the
+// shortest-path tree of every origin when it was requested, and is nil
+// otherwise.
+type DistanceMatrixOf[W Weight] struct {
+	Origins      []int
+	Destinations []int
+	Distances    []W
+	Trees        []*ShortestPathTreeOf[W]
+}
+
+// DistanceMatrix is the distance matrix of a graph with float64 weights.
+type DistanceMatrix = DistanceMatrixOf[float64]
+
+// At returns the distance from Origins[i] to Destinations[j].
+func (m *DistanceMatrixOf[W]) At(i, j int) W {
+	return m.Distances[i*len(m.Destinations)+j]
+}
+
+// Row returns the distances from Origins[i] to all destinations. The slice
+// aliases the matrix.
+func (m *DistanceMatrixOf[W]) Row(i int) []W {
+	cols := len(m.Destinations)
+	return m.Distances[i*cols : (i+1)*cols]
+}
+
+// Path returns the shortest path from Origins[i] to Destinations[j], or nil if
+// there is none or the trees were not kept.
+func (m *DistanceMatrixOf[W]) Path(i, j int) []int {
+	if m.Trees == nil {
+		return nil
+	}
+	return m.Trees[i].PathTo(m.Destinations[j])
+}
+
+// DistanceMatrix computes the distances from every origin to every destination
+// with one single-source computation per origin, run on the pool's workers.
+// With keepTrees set, the shortest-path tree of every origin is kept for path
+// reconstruction.
+func (p *Pool) DistanceMatrix(ctx context.Context, origins, destinations []int, keepTrees bool) (*DistanceMatrix, error) {
+	m, err := newDistanceMatrix[float64](p.proto.N, origins, destinations, keepTrees)
+	if err != nil {
+		return nil, err
+	}
+	err = p.run(ctx, len(origins), func(s *Solver, i int) {
+		tree, err := s.SolveAllContext(ctx, origins[i])
+		if err == nil {
+			m.fillRow(i, tree)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DistanceMatrix is the sequential form of Pool.DistanceMatrix.
+func (s *SolverOf[W]) DistanceMatrix(ctx context.Context, origins, destinations []int, keepTrees bool) (*DistanceMatrixOf[W], error) {
+	m, err := newDistanceMatrix[W](s.N, origins, destinations, keepTrees)
+	if err != nil {
+		return nil, err
+	}
+	for i, origin := range origins {
+		tree, err := s.SolveAllContext(ctx, origin)
+		if err != nil {
+			return nil, err
+		}
+		m.fillRow(i, tree)
+	}
+	return m, nil
+}
+
+func newDistanceMatrix[W Weight](n int, origins, destinations []int, keepTrees bool) (*DistanceMatrixOf[W], error) {
+	for _, v := range origins {
+		if err := checkVertex(v, n); err != nil {
+			return nil, fmt.Errorf("origin: %w", err)
+		}
+	}
+	for _, v := range destinations {
+		if err := checkVertex(v, n); err != nil {
+			return nil, fmt.Errorf("destination: %w", err)
+		}
+	}
+	m := &DistanceMatrixOf[W]{
+		Origins:      append([]int(nil), origins...),
+		Destinations: append([]int(nil), destinations...),
+		Distances:    make([]W, len(origins)*len(destinations)),
+	}
+	if keepTrees {
+		m.Trees = make([]*ShortestPathTreeOf[W], len(origins))
+	}
+	return m, nil
+}
+
+func (m *DistanceMatrixOf[W]) fillRow(i int, tree *ShortestPathTreeOf[W]) {
+	row := m.Row(i)
+	for j, v := range m.Destinations {
+		row[j] = tree.Distances[v]
+	}
+	if m.Trees != nil {
+		m.Trees[i] = tree
+	}
+}
@@ -0,0 +1,79 @@
+package bmssp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+func TestDistanceMatrix(t *testing.T) {
+	g := makeSparseGraph(90, 360, 91)
+	origins := []int{0, 7, 14, 21, 28}
+	destinations := []int{3, 9, 27, 81, 89, 0}
+
+	sequential, err := NewSolverWithOptions(g, Options{Algorithm: AlgorithmBMSSP}).DistanceMatrix(context.Background(), origins, destinations, true)
+	if err != nil {
+		t.Fatalf("Solver.DistanceMatrix failed: %v", err)
+	}
+	parallel, err := NewPool(g, 3, Options{}).DistanceMatrix(context.Background(), origins, destinations, false)
+	if err != nil {
+		t.Fatalf("Pool.DistanceMatrix failed: %v", err)
+	}
+	if parallel.Trees != nil || parallel.Path(0, 0) != nil {
+		t.Fatalf("expected no trees when not requested")
+	}
+
+	for i, origin := range origins {
+		for j, dest := range destinations {
+			want, _ := Dijkstra(g, origin, dest)
+			for _, m := range []*DistanceMatrix{sequential, parallel} {
+				got := m.At(i, j)
+				if math.IsInf(want, 1) != math.IsInf(got, 1) || (!math.IsInf(want, 1) && math.Abs(got-want) > 1e-9) {
+					t.Fatalf("distance mismatch %d->%d: %f vs %f", origin, dest, got, want)
+				}
+			}
+			if !math.IsInf(want, 1) {
+				assertValidPath(t, g, origin, dest, want, sequential.Path(i, j))
+			}
+		}
+	}
+
+	if _, err := NewSolver(g).DistanceMatrix(context.Background(), []int{0}, []int{90}, false); !errors.Is(err, ErrVertexOutOfRange) {
+		t.Fatalf("expected ErrVertexOutOfRange, got %v", err)
+	}
+}
+
+func TestGonumConverter_DistanceMatrix(t *testing.T) {
+	g := simple.NewWeightedDirectedGraph(0, 0)
+	nodes := make([]simple.Node, 4)
+	for i := range nodes {
+		nodes[i] = simple.Node((i + 1) * 100)
+		g.AddNode(nodes[i])
+	}
+	g.SetWeightedEdge(g.NewWeightedEdge(nodes[0], nodes[1], 2))
+	g.SetWeightedEdge(g.NewWeightedEdge(nodes[1], nodes[2], 3))
+	g.SetWeightedEdge(g.NewWeightedEdge(nodes[0], nodes[2], 10))
+	g.SetWeightedEdge(g.NewWeightedEdge(nodes[2], nodes[3], 1))
+
+	converter := NewGonumConverter(g)
+	m, err := converter.DistanceMatrix(context.Background(), []int64{100, 200}, []int64{300, 400, 100}, 2, true)
+	if err != nil {
+		t.Fatalf("DistanceMatrix failed: %v", err)
+	}
+	want := [][]float64{{5, 6, 0}, {3, 4, math.Inf(1)}}
+	for i := range want {
+		for j := range want[i] {
+			if m.At(i, j) != want[i][j] {
+				t.Fatalf("entry (%d,%d): got %f want %f", i, j, m.At(i, j), want[i][j])
+			}
+		}
+	}
+	assertValidGonumPath(t, g, 100, 400, 6, converter.ToGonumPath(m.Path(0, 1)))
+
+	if _, err := converter.DistanceMatrix(context.Background(), []int64{999}, []int64{100}, 1, false); err == nil {
+		t.Fatalf("expected error for unknown origin")
+	}
+}
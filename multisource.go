@@ -0,0 +1,117 @@
+package bmssp
+
+import "context"
+
+// SourceOf is a start vertex of a multi-source search. Offset is the distance
+// assigned to the vertex before any edge is relaxed, e.g. a dispatch cost.
+type SourceOf[W Weight] struct {
+	Vertex int
+	Offset W
+}
+
+// Source is a start vertex of a search over float64 weights.
+type Source = SourceOf[float64]
+
+// MultiSourceResultOf holds the result of a multi-source computation. For every
+// vertex it records the distance to the closest source (offsets included), the
+// predecessor on that shortest path and the source vertex the path starts
+// from. Unreachable vertices have an infinite distance, a predecessor of -1
+// and an origin of -1.
+type MultiSourceResultOf[W Weight] struct {
+	Distances    []W
+	Predecessors []int
+	Origins      []int
+}
+
+// MultiSourceResult is the result of a search over float64 weights.
+type MultiSourceResult = MultiSourceResultOf[float64]
+
+// DistanceTo returns the distance from the closest source to v, or infinity if
+// v is unreachable or not a vertex of the graph.
+func (r *MultiSourceResultOf[W]) DistanceTo(v int) W {
+	if v < 0 || v >= len(r.Distances) {
+		return infinity[W]()
+	}
+	return r.Distances[v]
+}
+
+// OriginOf returns the source closest to v, or -1 if v is unreachable.
+func (r *MultiSourceResultOf[W]) OriginOf(v int) int {
+	if v < 0 || v >= len(r.Origins) {
+		return -1
+	}
+	return r.Origins[v]
+}
+
+// PathTo returns the shortest path from the closest source to v, or nil if v is
+// unreachable.
+func (r *MultiSourceResultOf[W]) PathTo(v int) []int {
+	origin := r.OriginOf(v)
+	if origin == -1 {
+		return nil
+	}
+	return buildPath(r.Predecessors, origin, v)
+}
+
+// SolveMultiSource computes, for every vertex, the shortest distance from any
+// of the given sources and which source attains it. It returns nil if a source
+// is not a vertex of the graph or has a NaN offset.
+func (s *SolverOf[W]) SolveMultiSource(sources []SourceOf[W]) *MultiSourceResultOf[W] {
+	result, _ := s.SolveMultiSourceContext(context.Background(), sources)
+	return result
+}
+
+// SolveMultiSourceContext is like SolveMultiSource but reports invalid sources
+// with ErrVertexOutOfRange or ErrNaNDistance, and gives up once ctx is done and
+// returns ctx.Err().
+func (s *SolverOf[W]) SolveMultiSourceContext(ctx context.Context, sources []SourceOf[W]) (*MultiSourceResultOf[W], error) {
+	dist, prev, err := s.search(ctx, sources, s.inf, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiSourceResultOf[W]{
+		Distances:    dist,
+		Predecessors: prev,
+		Origins:      treeOrigins(dist, prev),
+	}, nil
+}
+
+// treeOrigins returns the root of every vertex in the forest described by
+// prev, or -1 for vertices with an infinite distance. Vertices on or below a
+// predecessor cycle have no root and also get -1.
+func treeOrigins[W Weight](dist []W, prev []int) []int {
+	const unknown, visiting = -2, -3
+	inf := infinity[W]()
+	origins := make([]int, len(dist))
+	for v := range origins {
+		origins[v] = unknown
+	}
+
+	stack := make([]int, 0, 16)
+	for v := range origins {
+		if origins[v] != unknown {
+			continue
+		}
+		curr := v
+		for origins[curr] == unknown && dist[curr] != inf && prev[curr] != -1 {
+			origins[curr] = visiting
+			stack = append(stack, curr)
+			curr = prev[curr]
+		}
+		root := origins[curr]
+		if root == visiting {
+			root = -1
+		} else if root == unknown {
+			root = -1
+			if dist[curr] != inf {
+				root = curr
+			}
+			origins[curr] = root
+		}
+		for _, u := range stack {
+			origins[u] = root
+		}
+		stack = stack[:0]
+	}
+	return origins
+}
@@ -0,0 +1,138 @@
+package bmssp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSolveMultiSource(t *testing.T) {
+	rng := rand.New(rand.NewSource(33))
+	for iter := 0; iter < 15; iter++ {
+		n := 15 + rng.Intn(15)
+		g := makeSparseGraph(n, 3*n*(n-1)/20, rng.Int63())
+
+		sources := make([]Source, 1+rng.Intn(4))
+		for i := range sources {
+			sources[i] = Source{Vertex: rng.Intn(n), Offset: rng.Float64() * 5}
+		}
+
+		// Reference: Dijkstra from a super source linked to every source.
+		ref := NewGraph(n + 1)
+		for u, edges := range g.Adj {
+			for _, edge := range edges {
+				ref.AddEdge(u, edge.To, edge.Weight)
+			}
+		}
+		for _, src := range sources {
+			ref.AddEdge(n, src.Vertex, src.Offset)
+		}
+
+		for _, force := range []bool{false, true} {
+			solver := NewSolver(g)
+			solver.ForceBMSSP = force
+			result := solver.SolveMultiSource(sources)
+			if result == nil {
+				t.Fatalf("expected result for sources %v", sources)
+			}
+
+			for v := 0; v < n; v++ {
+				want, _ := Dijkstra(ref, n, v)
+				got := result.DistanceTo(v)
+				if math.IsInf(want, 1) {
+					if !math.IsInf(got, 1) || result.OriginOf(v) != -1 || result.PathTo(v) != nil {
+						t.Fatalf("expected vertex %d unreachable, got dist=%f origin=%d", v, got, result.OriginOf(v))
+					}
+					continue
+				}
+				if math.Abs(got-want) > 1e-9 {
+					t.Fatalf("distance mismatch at %d: got %f want %f", v, got, want)
+				}
+
+				origin := result.OriginOf(v)
+				offset := math.Inf(1)
+				for _, src := range sources {
+					if src.Vertex == origin && src.Offset < offset {
+						offset = src.Offset
+					}
+				}
+				if math.IsInf(offset, 1) {
+					t.Fatalf("origin %d of vertex %d is not a source", origin, v)
+				}
+				assertValidPath(t, g, origin, v, got-offset, result.PathTo(v))
+			}
+		}
+	}
+}
+
+func TestSolveMultiSource_InvalidSource(t *testing.T) {
+	g := NewGraph(3)
+	solver := NewSolver(g)
+	if result := solver.SolveMultiSource([]Source{{Vertex: 0}, {Vertex: 3}}); result != nil {
+		t.Fatalf("expected nil result for invalid source, got %+v", result)
+	}
+}
+
+func TestSolveMultiSource_ZeroWeightCycle(t *testing.T) {
+	const hub = 50
+	g := NewGraph(hub + 1)
+	g.AddEdge(40, hub, 1)
+	for v := 1; v < hub; v++ {
+		if v == 40 || v == 41 || v == 49 {
+			continue
+		}
+		g.AddEdge(hub, v, 1)
+	}
+	g.AddEdge(hub, 41, 0)
+	g.AddEdge(41, 49, 0)
+	g.AddEdge(49, hub, 0)
+
+	sources := []Source{{Vertex: 40}, {Vertex: 0, Offset: 10}}
+	for _, force := range []bool{false, true} {
+		solver := NewSolver(g)
+		solver.ForceBMSSP = force
+		result := solver.SolveMultiSource(sources)
+		if result == nil {
+			t.Fatal("expected result")
+		}
+		for v := 0; v < g.Vertices; v++ {
+			if v == 0 {
+				if result.OriginOf(v) != 0 {
+					t.Fatalf("expected vertex 0 to be its own origin, got %d", result.OriginOf(v))
+				}
+				continue
+			}
+			if result.OriginOf(v) != 40 {
+				t.Fatalf("expected origin 40 for vertex %d, got %d", v, result.OriginOf(v))
+			}
+			want, _ := Dijkstra(g, 40, v)
+			assertValidPath(t, g, 40, v, want, result.PathTo(v))
+		}
+
+		within := solver.WithinDistance(sources[:1], 1)
+		if within == nil || len(within.Vertices) != 1 {
+			t.Fatalf("expected only vertex 40 within distance 1, got %+v", within)
+		}
+		within = solver.WithinDistance(sources[:1], 2)
+		if within == nil || len(within.Vertices) != 4 {
+			t.Fatalf("expected 40, 41, 49 and the hub within distance 2, got %+v", within)
+		}
+		for _, v := range within.Vertices {
+			if within.OriginOf(v) != 40 || within.PathTo(v) == nil {
+				t.Fatalf("expected path from 40 to %d, got %v", v, within.PathTo(v))
+			}
+		}
+	}
+}
+
+func TestTreeOrigins_Cycle(t *testing.T) {
+	dist := []float64{0, 1, 1, 1}
+	prev := []int{-1, 2, 3, 1}
+	origins := treeOrigins(dist, prev)
+	want := []int{0, -1, -1, -1}
+	for v := range want {
+		if origins[v] != want[v] {
+			t.Fatalf("origins = %v, want %v", origins, want)
+		}
+	}
+}
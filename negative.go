@@ -0,0 +1,198 @@
+package bmssp
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// NegativeCycleError reports a negative cycle. Cycle lists its vertices in
+// edge order and repeats the first vertex at the end. It matches
+// ErrNegativeCycle with errors.Is.
+type NegativeCycleError struct {
+	Cycle []int
+}
+
+func (e *NegativeCycleError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrNegativeCycle, e.Cycle)
+}
+
+// Is reports whether target is ErrNegativeCycle.
+func (e *NegativeCycleError) Is(target error) bool {
+	return target == ErrNegativeCycle
+}
+
+// BellmanFord computes shortest paths from source on a graph that may have
+// negative edge weights. It uses the queue-based (SPFA) variant of
+// Bellman-Ford, which only rescans vertices whose distance changed. If a
+// negative cycle is reachable from source it returns a *NegativeCycleError.
+func BellmanFord(g *Graph, source int) (*ShortestPathTree, error) {
+	return BellmanFordContext(context.Background(), g, source)
+}
+
+// BellmanFordContext is like BellmanFord but gives up once ctx is done and
+// returns ctx.Err().
+func BellmanFordContext(ctx context.Context, g *Graph, source int) (*ShortestPathTree, error) {
+	c := g.CSR()
+	if err := checkVertex(source, c.Vertices); err != nil {
+		return nil, err
+	}
+	dist, prev, err := bellmanFordSearch(ctx, c, []Source{{Vertex: source}})
+	if err != nil {
+		return nil, err
+	}
+	return &ShortestPathTree{Source: source, Distances: dist, Predecessors: prev}, nil
+}
+
+// bellmanFordSearch runs SPFA from the given sources. hops[v] is the number of
+// edges on the walk that gave v its distance; a walk of n or more edges repeats
+// a vertex, which only happens when a negative cycle feeds it. Predecessors
+// may have moved on since, so the chain of v is checked for the cycle and the
+// search continues if it is not closed yet. Any cycle of predecessors is
+// negative.
+func bellmanFordSearch(ctx context.Context, g *CSRGraph, sources []Source) ([]float64, []int, error) {
+	n := g.Vertices
+	dist := make([]float64, n)
+	prev := make([]int, n)
+	hops := make([]int, n)
+	queued := make([]bool, n)
+	for i := 0; i < n; i++ {
+		dist[i] = math.Inf(1)
+		prev[i] = -1
+	}
+
+	var queue []int
+	for _, src := range sources {
+		if src.Offset < dist[src.Vertex] {
+			dist[src.Vertex] = src.Offset
+		}
+		if !queued[src.Vertex] {
+			queued[src.Vertex] = true
+			queue = append(queue, src.Vertex)
+		}
+	}
+
+	for step := 0; len(queue) > 0; step++ {
+		if err := checkContext(ctx, step); err != nil {
+			return nil, nil, err
+		}
+		u := queue[0]
+		queue = queue[1:]
+		queued[u] = false
+
+		for e := g.Offsets[u]; e < g.Offsets[u+1]; e++ {
+			v := g.Targets[e]
+			newDist := dist[u] + g.Weights[e]
+			if newDist >= dist[v] {
+				continue
+			}
+			dist[v] = newDist
+			prev[v] = u
+			hops[v] = hops[u] + 1
+			if hops[v] >= n {
+				if cycle := predecessorCycle(prev, v); cycle != nil {
+					return nil, nil, &NegativeCycleError{Cycle: cycle}
+				}
+			}
+			if !queued[v] {
+				queued[v] = true
+				queue = append(queue, v)
+			}
+		}
+	}
+	return dist, prev, nil
+}
+
+// predecessorCycle follows the predecessors of v and returns the cycle it runs
+// into, or nil if the chain ends at a source.
+func predecessorCycle(prev []int, v int) []int {
+	index := make(map[int]int)
+	var chain []int
+	for ; v != -1; v = prev[v] {
+		if i, ok := index[v]; ok {
+			// chain[i:] follows predecessors, i.e. runs against the edges.
+			cycle := append([]int(nil), chain[i:]...)
+			for l, r := 0, len(cycle)-1; l < r; l, r = l+1, r-1 {
+				cycle[l], cycle[r] = cycle[r], cycle[l]
+			}
+			return append(cycle, cycle[0])
+		}
+		index[v] = len(chain)
+		chain = append(chain, v)
+	}
+	return nil
+}
+
+// Johnson answers shortest path queries on a graph with negative edge weights
+// but no negative cycles. Potentials are computed once with Bellman-Ford from
+// a virtual source connected to every vertex; reweighting each edge u->v to
+// w + Potentials[u] - Potentials[v] makes all weights non-negative without
+// changing which paths are shortest, so the queries run on an ordinary Solver.
+// Like Solver, a Johnson is not safe for concurrent use.
+type Johnson struct {
+	Potentials []float64
+	Solver     *Solver
+}
+
+// NewJohnson computes the potentials of g and prepares a solver configured by
+// opts on the reweighted graph. It returns a *NegativeCycleError if g has a
+// negative cycle.
+func NewJohnson(g *Graph, opts Options) (*Johnson, error) {
+	return NewJohnsonContext(context.Background(), g, opts)
+}
+
+// NewJohnsonContext is like NewJohnson but gives up once ctx is done and
+// returns ctx.Err().
+func NewJohnsonContext(ctx context.Context, g *Graph, opts Options) (*Johnson, error) {
+	c := g.CSR()
+	sources := make([]Source, c.Vertices)
+	for v := range sources {
+		sources[v] = Source{Vertex: v}
+	}
+	potentials, _, err := bellmanFordSearch(ctx, c, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	// The reweighted graph shares the topology of c. Rounding can leave a
+	// tight edge slightly below zero, which is clamped.
+	weights := make([]float64, c.Edges)
+	for u := 0; u < c.Vertices; u++ {
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			weights[e] = math.Max(0, c.Weights[e]+potentials[u]-potentials[c.Targets[e]])
+		}
+	}
+	reweighted := &CSRGraph{
+		Vertices: c.Vertices,
+		Edges:    c.Edges,
+		Offsets:  c.Offsets,
+		Targets:  c.Targets,
+		Weights:  weights,
+	}
+	return &Johnson{Potentials: potentials, Solver: NewCSRSolverWithOptions(reweighted, opts)}, nil
+}
+
+// Solve returns the shortest distance and path from source to goal in the
+// original weights, or +Inf and nil if goal is unreachable.
+func (j *Johnson) Solve(source, goal int) (float64, []int) {
+	dist, path := j.Solver.Solve(source, goal)
+	if math.IsInf(dist, 1) {
+		return dist, path
+	}
+	return dist - j.Potentials[source] + j.Potentials[goal], path
+}
+
+// SolveAll returns the shortest-path tree from source in the original weights.
+// It returns nil if source is not a vertex of the graph.
+func (j *Johnson) SolveAll(source int) *ShortestPathTree {
+	tree := j.Solver.SolveAll(source)
+	if tree == nil {
+		return nil
+	}
+	for v, d := range tree.Distances {
+		if !math.IsInf(d, 1) {
+			tree.Distances[v] = d - j.Potentials[source] + j.Potentials[v]
+		}
+	}
+	return tree
+}
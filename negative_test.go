@@ -0,0 +1,97 @@
+package bmssp
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// makePotentialGraph returns a graph with negative weights but no negative
+// cycles: every edge u->v of g is shifted by p[u] - p[v], which changes each
+// path by p[source] - p[goal].
+func makePotentialGraph(g *Graph, seed int64) (*Graph, []float64) {
+	rng := rand.New(rand.NewSource(seed))
+	p := make([]float64, g.Vertices)
+	for v := range p {
+		p[v] = rng.Float64() * 20
+	}
+	out := NewGraph(g.Vertices)
+	for u, edges := range g.Adj {
+		for _, e := range edges {
+			out.AddEdge(u, e.To, e.Weight+p[u]-p[e.To])
+		}
+	}
+	return out, p
+}
+
+func TestBellmanFord(t *testing.T) {
+	rng := rand.New(rand.NewSource(181))
+	for iter := 0; iter < 20; iter++ {
+		n := 10 + rng.Intn(50)
+		base := makeSparseGraph(n, 3*n, rng.Int63())
+		g, p := makePotentialGraph(base, rng.Int63())
+		source := rng.Intn(n)
+		tree, err := BellmanFord(g, source)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		j, err := NewJohnson(g, Options{Algorithm: AlgorithmBMSSP})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		all := j.SolveAll(source)
+		for goal := 0; goal < n; goal++ {
+			want, _ := Dijkstra(base, source, goal)
+			if math.IsInf(want, 1) {
+				if !math.IsInf(tree.DistanceTo(goal), 1) || !math.IsInf(all.DistanceTo(goal), 1) {
+					t.Fatalf("expected %d unreachable", goal)
+				}
+				continue
+			}
+			want += p[source] - p[goal]
+			if got := tree.DistanceTo(goal); math.Abs(got-want) > 1e-9 {
+				t.Fatalf("Bellman-Ford %d->%d: %f vs %f", source, goal, got, want)
+			}
+			assertValidPath(t, g, source, goal, want, tree.PathTo(goal))
+			if got := all.DistanceTo(goal); math.Abs(got-want) > 1e-9 {
+				t.Fatalf("Johnson tree %d->%d: %f vs %f", source, goal, got, want)
+			}
+			got, path := j.Solve(source, goal)
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("Johnson %d->%d: %f vs %f", source, goal, got, want)
+			}
+			assertValidPath(t, g, source, goal, want, path)
+		}
+	}
+}
+
+func TestBellmanFord_NegativeCycle(t *testing.T) {
+	g := NewGraph(5)
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 2, 2)
+	g.AddEdge(2, 3, -4)
+	g.AddEdge(3, 1, 1)
+	g.AddEdge(3, 4, 1)
+
+	_, err := BellmanFord(g, 0)
+	var cycleErr *NegativeCycleError
+	if !errors.Is(err, ErrNegativeCycle) || !errors.As(err, &cycleErr) {
+		t.Fatalf("expected NegativeCycleError, got %v", err)
+	}
+	cycle := cycleErr.Cycle
+	if len(cycle) != 4 || cycle[0] != cycle[len(cycle)-1] {
+		t.Fatalf("expected closed cycle of three edges, got %v", cycle)
+	}
+	if weight, ok := pathDistance(g, cycle); !ok || weight >= 0 {
+		t.Fatalf("expected negative cycle, got %v with weight %f", cycle, weight)
+	}
+
+	if _, err := NewJohnson(g, Options{}); !errors.Is(err, ErrNegativeCycle) {
+		t.Fatalf("expected ErrNegativeCycle from Johnson, got %v", err)
+	}
+	// The cycle is not reachable from 4.
+	if _, err := BellmanFord(g, 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package bmssp
+
+// DefaultFallbackThreshold is the vertex count below which AlgorithmAuto
+// answers queries with Dijkstra instead of BMSSP.
+const DefaultFallbackThreshold = 1000
+
+// Algorithm selects how a Solver answers queries.
+type Algorithm int
+
+const (
+	// AlgorithmAuto uses Dijkstra for graphs with fewer vertices than the
+	// fallback threshold and BMSSP otherwise.
+	AlgorithmAuto Algorithm = iota
+	// AlgorithmBMSSP always uses BMSSP.
+	AlgorithmBMSSP
+	// AlgorithmDijkstra always uses Dijkstra.
+	AlgorithmDijkstra
+)
+
+func (a Algorithm) String() string {
+	switch a {
+	case AlgorithmAuto:
+		return "auto"
+	case AlgorithmBMSSP:
+		return "bmssp"
+	case AlgorithmDijkstra:
+		return "dijkstra"
+	}
+	return "unknown"
+}
+
+// Options configures a Solver. The zero value selects the defaults, so only
+// the settings being changed need to be filled in.
+type Options struct {
+	// Algorithm selects between BMSSP and Dijkstra.
+	Algorithm Algorithm
+	// FallbackThreshold is the vertex count below which AlgorithmAuto uses
+	// Dijkstra. Zero or less means DefaultFallbackThreshold.
+	FallbackThreshold int
+	// K and T override the parameters k = ⌊log^{1/3} n⌋ and t = ⌊log^{2/3} n⌋
+	// of the paper when positive. The number of recursion levels is derived
+	// from T.
+	K int
+	T int
+	// DisableTransformation runs BMSSP on the graph as given instead of on its
+	// constant-degree transformation. Results are the same; only the running
+	// time changes.
+	DisableTransformation bool
+	// BidirectionalFallback answers point-to-point queries that use Dijkstra
+	// with BidirectionalDijkstra. Queries for whole trees are unaffected.
+	BidirectionalFallback bool
+}
+
+// NewSolverWithOptions creates a solver for graph configured by opts.
+func NewSolverWithOptions[W Weight](graph *GraphOf[W], opts Options) *SolverOf[W] {
+	s := NewSolver(graph)
+	s.Options = opts
+	s.applyParameters()
+	return s
+}
+
+// NewCSRSolverWithOptions creates a solver for c configured by opts.
+func NewCSRSolverWithOptions[W Weight](c *CSRGraphOf[W], opts Options) *SolverOf[W] {
+	s := NewCSRSolver(c)
+	s.Options = opts
+	s.applyParameters()
+	return s
+}
+
+// useDijkstra reports whether queries should bypass BMSSP.
+func (s *SolverOf[W]) useDijkstra() bool {
+	switch s.Options.Algorithm {
+	case AlgorithmDijkstra:
+		return true
+	case AlgorithmBMSSP:
+		return false
+	}
+	if s.ForceBMSSP {
+		return false
+	}
+	threshold := s.Options.FallbackThreshold
+	if threshold <= 0 {
+		threshold = DefaultFallbackThreshold
+	}
+	return s.N < threshold
+}
+
+// applyParameters sets K, T and Levels from the graph size and the overrides in
+// Options.
+func (s *SolverOf[W]) applyParameters() {
+	k, t := computeParameters(s.N)
+	if s.Options.K > 0 {
+		k = s.Options.K
+	}
+	if s.Options.T > 0 {
+		t = s.Options.T
+	}
+	s.K = k
+	s.T = t
+	s.Levels = computeLevels(s.N, t)
+}
+
+// identityTransformation maps c onto itself, for running BMSSP without the
+// constant-degree reduction.
+func identityTransformation[W Weight](c *CSRGraphOf[W]) *TransformationOf[W] {
+	ids := make([]int, c.Vertices)
+	for v := range ids {
+		ids[v] = v
+	}
+	return &TransformationOf[W]{
+		CSR:       c,
+		OrigToNew: ids,
+		NewToOrig: ids,
+	}
+}
+
+// transformationKey identifies the inputs a cached transformation was built
+// from; the cache is rebuilt whenever any of them changes.
+type transformationKey struct {
+	version  uint64
+	identity bool
+}
@@ -0,0 +1,73 @@
+package bmssp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestOptions_AlgorithmSelection(t *testing.T) {
+	g := makeSparseGraph(50, 200, 71)
+	cases := []struct {
+		opts    Options
+		bmssp   bool
+		message string
+	}{
+		{Options{}, false, "auto below default threshold"},
+		{Options{FallbackThreshold: 10}, true, "auto above custom threshold"},
+		{Options{Algorithm: AlgorithmBMSSP}, true, "forced BMSSP"},
+		{Options{Algorithm: AlgorithmDijkstra, FallbackThreshold: 10}, false, "forced Dijkstra"},
+	}
+	for _, tc := range cases {
+		stats := &Stats{}
+		solver := NewSolverWithOptions(g, tc.opts)
+		solver.Stats = stats
+		solver.Solve(0, 49)
+		if got := stats.Calls > 0; got != tc.bmssp {
+			t.Fatalf("%s: expected BMSSP=%v, got calls=%d fallbacks=%d", tc.message, tc.bmssp, stats.Calls, stats.DijkstraFallbacks)
+		}
+	}
+}
+
+func TestOptions_ParametersAndTransformation(t *testing.T) {
+	rng := rand.New(rand.NewSource(73))
+	g := makeSparseGraph(60, 240, 75)
+	variants := []Options{
+		{Algorithm: AlgorithmBMSSP, K: 1, T: 1},
+		{Algorithm: AlgorithmBMSSP, K: 4, T: 3},
+		{Algorithm: AlgorithmBMSSP, DisableTransformation: true},
+		{Algorithm: AlgorithmBMSSP, DisableTransformation: true, K: 2, T: 2},
+	}
+	for _, opts := range variants {
+		stats := &Stats{}
+		solver := NewSolverWithOptions(g, opts)
+		solver.Stats = stats
+		for trial := 0; trial < 10; trial++ {
+			source, target := rng.Intn(60), rng.Intn(60)
+			want, _ := Dijkstra(g, source, target)
+			got, path := solver.Solve(source, target)
+			if math.IsInf(want, 1) {
+				if !math.IsInf(got, 1) {
+					t.Fatalf("%+v: expected no path, got %f", opts, got)
+				}
+				continue
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("%+v: distance mismatch %f vs %f", opts, got, want)
+			}
+			assertValidPath(t, g, source, target, got, path)
+		}
+
+		if opts.K > 0 && solver.internal.K != opts.K {
+			t.Fatalf("expected K=%d, got %d", opts.K, solver.internal.K)
+		}
+		if opts.T > 0 && solver.internal.T != opts.T {
+			t.Fatalf("expected T=%d, got %d", opts.T, solver.internal.T)
+		}
+		if opts.DisableTransformation {
+			if stats.Transformations != 0 || solver.internal.N != g.Vertices {
+				t.Fatalf("expected BMSSP on the original graph")
+			}
+		}
+	}
+}
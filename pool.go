@@ -0,0 +1,174 @@
+package bmssp
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// QueryResult is the answer to one query of a batch. Err is set when the query
+// itself is invalid, e.g. ErrVertexOutOfRange.
+type QueryResult struct {
+	Distance float64
+	Path     []int
+	Err      error
+}
+
+// Pool answers batches of queries concurrently over a single immutable graph.
+// The graph and its constant-degree transformation are built once and shared
+// read-only by all workers; each worker owns a Solver whose distance arrays are
+// reused from query to query. A Pool is safe for concurrent use, and the total
+// number of running workers never exceeds its size, even across concurrent
+// batches.
+type Pool struct {
+	proto *Solver
+	size  int
+
+	mu       sync.Mutex
+	prepared bool
+	created  int
+	idle     chan *Solver
+}
+
+// NewPool creates a pool of the given number of workers over a snapshot of
+// graph; later changes to graph are not seen by the pool. A non-positive worker
+// count means runtime.GOMAXPROCS(0).
+func NewPool(graph *Graph, workers int, opts Options) *Pool {
+	return NewCSRPool(graph.CSR(), workers, opts)
+}
+
+// NewCSRPool creates a pool of the given number of workers over c.
+func NewCSRPool(c *CSRGraph, workers int, opts Options) *Pool {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &Pool{
+		proto: NewCSRSolverWithOptions(c, opts),
+		size:  workers,
+		idle:  make(chan *Solver, workers),
+	}
+}
+
+// SolveBatch answers every point-to-point query and returns the results in
+// input order. If ctx is done before the batch completes, it returns nil and
+// ctx.Err().
+func (p *Pool) SolveBatch(ctx context.Context, queries []Query) ([]QueryResult, error) {
+	results := make([]QueryResult, len(queries))
+	err := p.run(ctx, len(queries), func(s *Solver, i int) {
+		dist, path, err := s.SolveContext(ctx, queries[i].Source, queries[i].Goal)
+		results[i] = QueryResult{Distance: dist, Path: path, Err: err}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SolveAllBatch computes a shortest-path tree from every source and returns
+// them in input order. Invalid sources yield a nil tree. If ctx is done before
+// the batch completes, it returns nil and ctx.Err().
+func (p *Pool) SolveAllBatch(ctx context.Context, sources []int) ([]*ShortestPathTree, error) {
+	trees := make([]*ShortestPathTree, len(sources))
+	err := p.run(ctx, len(sources), func(s *Solver, i int) {
+		trees[i], _ = s.SolveAllContext(ctx, sources[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trees, nil
+}
+
+// run calls fn for every index in [0, n) on at most p.size workers.
+func (p *Pool) run(ctx context.Context, n int, fn func(s *Solver, i int)) error {
+	if err := p.prepare(ctx); err != nil {
+		return err
+	}
+
+	workers := p.size
+	if n < workers {
+		workers = n
+	}
+	var next int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s, err := p.acquire(ctx)
+			if err != nil {
+				return
+			}
+			defer p.release(s)
+			for ctx.Err() == nil {
+				i := int(atomic.AddInt64(&next, 1) - 1)
+				if i >= n {
+					return
+				}
+				fn(s, i)
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// prepare builds the shared transformation the first time a batch needs it.
+func (p *Pool) prepare(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.prepared || p.proto.useDijkstra() {
+		return nil
+	}
+	if _, _, err := p.proto.transformed(ctx); err != nil {
+		return err
+	}
+	p.prepared = true
+	return nil
+}
+
+func (p *Pool) acquire(ctx context.Context) (*Solver, error) {
+	select {
+	case s := <-p.idle:
+		return s, nil
+	default:
+	}
+
+	p.mu.Lock()
+	if p.created < p.size {
+		p.created++
+		s := p.proto.fork()
+		p.mu.Unlock()
+		return s, nil
+	}
+	p.mu.Unlock()
+
+	select {
+	case s := <-p.idle:
+		return s, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *Pool) release(s *Solver) {
+	p.idle <- s
+}
+
+// fork returns a solver over the same graph and options that shares the cached
+// transformation read-only but owns its search state.
+func (s *SolverOf[W]) fork() *SolverOf[W] {
+	w := newSolver[W](s.N)
+	w.Graph = s.Graph
+	w.csr = s.csr
+	w.Options = s.Options
+	w.ForceBMSSP = s.ForceBMSSP
+	w.build = s.build
+	w.applyParameters()
+	if s.transform != nil {
+		w.transform = s.transform
+		w.transformKey = s.transformKey
+		w.internal = NewCSRSolver(s.transform.CSR)
+	}
+	return w
+}
@@ -0,0 +1,102 @@
+package bmssp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestPool_SolveBatch(t *testing.T) {
+	g := makeSparseGraph(120, 480, 81)
+	rng := rand.New(rand.NewSource(83))
+	queries := make([]Query, 200)
+	for i := range queries {
+		queries[i] = Query{Source: rng.Intn(120), Goal: rng.Intn(120)}
+	}
+	queries = append(queries, Query{Source: 0, Goal: 500})
+
+	for _, algorithm := range []Algorithm{AlgorithmBMSSP, AlgorithmDijkstra} {
+		pool := NewPool(g, 4, Options{Algorithm: algorithm})
+		results, err := pool.SolveBatch(context.Background(), queries)
+		if err != nil {
+			t.Fatalf("%v: SolveBatch failed: %v", algorithm, err)
+		}
+		if len(results) != len(queries) {
+			t.Fatalf("%v: expected %d results, got %d", algorithm, len(queries), len(results))
+		}
+		for i, q := range queries[:len(queries)-1] {
+			want, _ := Dijkstra(g, q.Source, q.Goal)
+			got := results[i]
+			if got.Err != nil {
+				t.Fatalf("%v: unexpected error for %v: %v", algorithm, q, got.Err)
+			}
+			if math.IsInf(want, 1) {
+				if !math.IsInf(got.Distance, 1) || got.Path != nil {
+					t.Fatalf("%v: expected no path for %v", algorithm, q)
+				}
+				continue
+			}
+			if math.Abs(got.Distance-want) > 1e-9 {
+				t.Fatalf("%v: distance mismatch for %v: %f vs %f", algorithm, q, got.Distance, want)
+			}
+			assertValidPath(t, g, q.Source, q.Goal, got.Distance, got.Path)
+		}
+		if last := results[len(results)-1]; !errors.Is(last.Err, ErrVertexOutOfRange) {
+			t.Fatalf("%v: expected ErrVertexOutOfRange for invalid query, got %v", algorithm, last.Err)
+		}
+	}
+}
+
+func TestPool_SolveAllBatchConcurrent(t *testing.T) {
+	g := makeSparseGraph(80, 320, 85)
+	pool := NewPool(g, 3, Options{Algorithm: AlgorithmBMSSP})
+	sources := []int{0, 5, 10, 15, 20, 25, 30, 35}
+
+	var wg sync.WaitGroup
+	for batch := 0; batch < 4; batch++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			trees, err := pool.SolveAllBatch(context.Background(), sources)
+			if err != nil {
+				t.Errorf("SolveAllBatch failed: %v", err)
+				return
+			}
+			for i, tree := range trees {
+				if tree == nil || tree.Source != sources[i] {
+					t.Errorf("tree %d out of order", i)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	trees, err := pool.SolveAllBatch(context.Background(), sources)
+	if err != nil {
+		t.Fatalf("SolveAllBatch failed: %v", err)
+	}
+	for _, tree := range trees {
+		assertTreeMatchesDijkstra(t, g, tree)
+	}
+	if pool.created > 3 {
+		t.Fatalf("expected at most 3 worker solvers, got %d", pool.created)
+	}
+}
+
+func TestPool_Canceled(t *testing.T) {
+	g := makeSparseGraph(50, 200, 87)
+	pool := NewPool(g, 2, Options{Algorithm: AlgorithmBMSSP})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := pool.SolveBatch(ctx, []Query{{0, 1}, {1, 2}}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, err := pool.SolveBatch(context.Background(), []Query{{0, 1}}); err != nil {
+		t.Fatalf("pool unusable after cancellation: %v", err)
+	}
+}
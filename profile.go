@@ -0,0 +1,216 @@
+package bmssp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Profiles holds several named weight columns over one topology, e.g. travel
+// times for cars, bikes and pedestrians on the same street network. Every
+// profile shares the offsets and targets of the topology and adds only its own
+// weight slice, and the constant-degree transformation is built once and
+// reweighted per profile. Profiles is safe for concurrent use.
+type Profiles struct {
+	topology *CSRGraph
+
+	mu         sync.Mutex
+	names      []string
+	graphs     map[string]*CSRGraph
+	transform  *Transformation
+	reweighted map[*CSRGraph]*Transformation
+}
+
+// NewProfiles creates profiles over a snapshot of the topology of g, with the
+// weights of g as the profile called name. Later changes to g are not seen.
+func NewProfiles(g *Graph, name string) *Profiles {
+	return NewCSRProfiles(g.CSR(), name)
+}
+
+// NewCSRProfiles creates profiles over the topology of c, with c itself as the
+// profile called name.
+func NewCSRProfiles(c *CSRGraph, name string) *Profiles {
+	return &Profiles{
+		topology:   c,
+		names:      []string{name},
+		graphs:     map[string]*CSRGraph{name: c},
+		reweighted: make(map[*CSRGraph]*Transformation),
+	}
+}
+
+// Set adds the profile called name, or replaces it if it exists. weights holds
+// one weight per edge in CSR order, as described for EdgeFilter, and is used
+// without copying, so it must not be modified afterwards. A weight slice of the
+// wrong length is rejected with ErrProfileSize, and invalid weights with
+// ErrNegativeWeight or ErrNaNWeight. Solvers and pools created before a profile
+// is replaced keep its old weights.
+func (p *Profiles) Set(name string, weights []float64) error {
+	c := p.topology
+	if len(weights) != c.Edges {
+		return fmt.Errorf("%w: profile %q has %d weights for %d edges", ErrProfileSize, name, len(weights), c.Edges)
+	}
+	for u := 0; u < c.Vertices; u++ {
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			if err := checkWeight(u, c.Targets[e], weights[e]); err != nil {
+				return fmt.Errorf("profile %q: %w", name, err)
+			}
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if old, ok := p.graphs[name]; ok {
+		delete(p.reweighted, old)
+	} else {
+		p.names = append(p.names, name)
+	}
+	p.graphs[name] = &CSRGraph{
+		Vertices: c.Vertices,
+		Edges:    c.Edges,
+		Offsets:  c.Offsets,
+		Targets:  c.Targets,
+		Weights:  weights,
+	}
+	return nil
+}
+
+// Names returns the profile names in the order they were added.
+func (p *Profiles) Names() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.names...)
+}
+
+// Graph returns the graph of the profile called name, for use with DijkstraCSR
+// and the other functions on compressed graphs. It returns ErrUnknownProfile if
+// there is no such profile.
+func (p *Profiles) Graph(name string) (*CSRGraph, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.graph(name)
+}
+
+func (p *Profiles) graph(name string) (*CSRGraph, error) {
+	c, ok := p.graphs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProfile, name)
+	}
+	return c, nil
+}
+
+// Transformation returns the constant-degree transformation of the profile
+// called name. Its vertex mappings, offsets and targets are shared with every
+// other profile.
+func (p *Profiles) Transformation(name string) (*Transformation, error) {
+	return p.TransformationContext(context.Background(), name)
+}
+
+// TransformationContext is like Transformation but gives up once ctx is done
+// and returns ctx.Err().
+func (p *Profiles) TransformationContext(ctx context.Context, name string) (*Transformation, error) {
+	c, err := p.Graph(name)
+	if err != nil {
+		return nil, err
+	}
+	return p.transformation(ctx, c)
+}
+
+// transformation returns the transformation of c, a graph some profile has or
+// had. Only transformations of current profiles are cached, so a replaced
+// profile is reweighted again for each solver that still uses it.
+func (p *Profiles) transformation(ctx context.Context, c *CSRGraph) (*Transformation, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t, ok := p.reweighted[c]; ok {
+		return t, nil
+	}
+	if p.transform == nil {
+		t, err := NewConstantDegreeCSRContext(ctx, p.topology)
+		if err != nil {
+			return nil, err
+		}
+		p.transform = t
+	}
+	t := p.transform.reweight(p.topology, c.Weights)
+	for _, current := range p.graphs {
+		if current == c {
+			p.reweighted[c] = t
+			break
+		}
+	}
+	return t, nil
+}
+
+// Solver returns a solver for the profile called name configured by opts. Its
+// transformation is shared with the other profiles.
+func (p *Profiles) Solver(name string, opts Options) (*Solver, error) {
+	c, err := p.Graph(name)
+	if err != nil {
+		return nil, err
+	}
+	s := NewCSRSolverWithOptions(c, opts)
+	s.build = p.builder(c)
+	return s, nil
+}
+
+// Pool returns a pool of the given number of workers for the profile called
+// name, like NewCSRPool.
+func (p *Profiles) Pool(name string, workers int, opts Options) (*Pool, error) {
+	c, err := p.Graph(name)
+	if err != nil {
+		return nil, err
+	}
+	pool := NewCSRPool(c, workers, opts)
+	pool.proto.build = p.builder(c)
+	return pool, nil
+}
+
+// builder returns the transformation builder of a solver for c. It holds on
+// to c rather than the profile name, so that replacing the profile does not
+// change the weights the solver works with.
+func (p *Profiles) builder(c *CSRGraph) func(ctx context.Context) (*Transformation, error) {
+	return func(ctx context.Context) (*Transformation, error) {
+		return p.transformation(ctx, c)
+	}
+}
+
+// reweight returns t with the weights of its edges taken from weights, a weight
+// column over the topology of orig that t was built from. Each edge between two
+// cycles gets the smallest weight of the original edges it stands for; the
+// edges inside a cycle keep their zero weight. Everything but the weights is
+// shared with t.
+func (t *TransformationOf[W]) reweight(orig *CSRGraphOf[W], weights []W) *TransformationOf[W] {
+	origins := t.edgeOrigins(orig)
+	c := t.CSR
+	out := make([]W, c.Edges)
+	for e := range out {
+		lo, hi := origins.lo[e], origins.hi[e]
+		if lo == hi {
+			out[e] = c.Weights[e]
+			continue
+		}
+		w := weights[origins.sorted[lo]]
+		for _, o := range origins.sorted[lo+1 : hi] {
+			if weights[o] < w {
+				w = weights[o]
+			}
+		}
+		out[e] = w
+	}
+
+	r := &TransformationOf[W]{
+		CSR: &CSRGraphOf[W]{
+			Vertices: c.Vertices,
+			Edges:    c.Edges,
+			Offsets:  c.Offsets,
+			Targets:  c.Targets,
+			Weights:  out,
+		},
+		OrigToNew: t.OrigToNew,
+		NewToOrig: t.NewToOrig,
+		origins:   &transformOrigins{origins: origins},
+	}
+	// The origins depend on the topology only and are already built.
+	r.origins.once.Do(func() {})
+	return r
+}
@@ -0,0 +1,211 @@
+package bmssp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	rng := rand.New(rand.NewSource(241))
+	g := makeSparseGraph(150, 600, 243)
+	// A parallel edge whose weight ranks differently in each profile.
+	g.AddEdge(0, g.Adj[0][0].To, 1)
+	c := g.CSR()
+
+	profiles := NewProfiles(g, "car")
+	bike := make([]float64, c.Edges)
+	walk := make([]float64, c.Edges)
+	for e := range bike {
+		bike[e] = rng.Float64() * 20
+		walk[e] = rng.Float64() * 50
+	}
+	if err := profiles.Set("bike", bike); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := profiles.Set("walk", walk); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if names := profiles.Names(); len(names) != 3 || names[0] != "car" || names[1] != "bike" || names[2] != "walk" {
+		t.Fatalf("unexpected names %v", names)
+	}
+
+	var first *Transformation
+	for _, name := range profiles.Names() {
+		pc, err := profiles.Graph(name)
+		if err != nil {
+			t.Fatalf("Graph(%q) failed: %v", name, err)
+		}
+		if &pc.Targets[0] != &c.Targets[0] || &pc.Offsets[0] != &c.Offsets[0] {
+			t.Fatalf("%s: topology is not shared", name)
+		}
+		reference := pc.ToGraph()
+
+		transform, err := profiles.Transformation(name)
+		if err != nil {
+			t.Fatalf("Transformation(%q) failed: %v", name, err)
+		}
+		if first == nil {
+			first = transform
+		} else if &transform.CSR.Targets[0] != &first.CSR.Targets[0] || &transform.NewToOrig[0] != &first.NewToOrig[0] {
+			t.Fatalf("%s: transformation structure is not shared", name)
+		}
+		want := NewConstantDegreeCSR(pc)
+		for v := 0; v < c.Vertices; v++ {
+			d1, _ := DijkstraCSR(transform.CSR, transform.OrigToNew[0], transform.OrigToNew[v])
+			d2, _ := DijkstraCSR(want.CSR, want.OrigToNew[0], want.OrigToNew[v])
+			if d1 != d2 {
+				t.Fatalf("%s: transformed distance to %d is %f, want %f", name, v, d1, d2)
+			}
+		}
+
+		solver, err := profiles.Solver(name, Options{Algorithm: AlgorithmBMSSP})
+		if err != nil {
+			t.Fatalf("Solver(%q) failed: %v", name, err)
+		}
+		queries := make([]Query, 20)
+		for i := range queries {
+			queries[i] = Query{Source: rng.Intn(c.Vertices), Goal: rng.Intn(c.Vertices)}
+		}
+		for _, q := range queries {
+			want, _ := DijkstraCSR(pc, q.Source, q.Goal)
+			got, path := solver.Solve(q.Source, q.Goal)
+			if math.IsInf(want, 1) {
+				if !math.IsInf(got, 1) {
+					t.Fatalf("%s: expected no path for %v, got %f", name, q, got)
+				}
+				continue
+			}
+			if math.Abs(got-want) > 1e-9 {
+				t.Fatalf("%s: distance mismatch for %v: %f vs %f", name, q, got, want)
+			}
+			assertValidPath(t, reference, q.Source, q.Goal, want, path)
+		}
+		if solver.transform != transform {
+			t.Fatalf("%s: solver does not use the shared transformation", name)
+		}
+
+		pool, err := profiles.Pool(name, 2, Options{Algorithm: AlgorithmBMSSP})
+		if err != nil {
+			t.Fatalf("Pool(%q) failed: %v", name, err)
+		}
+		results, err := pool.SolveBatch(context.Background(), queries)
+		if err != nil {
+			t.Fatalf("%s: SolveBatch failed: %v", name, err)
+		}
+		for i, q := range queries {
+			want, _ := DijkstraCSR(pc, q.Source, q.Goal)
+			if got := results[i].Distance; got != want && math.Abs(got-want) > 1e-9 {
+				t.Fatalf("%s: pool distance mismatch for %v: %f vs %f", name, q, got, want)
+			}
+		}
+	}
+}
+
+func TestProfiles_Errors(t *testing.T) {
+	g := makeSparseGraph(20, 60, 245)
+	profiles := NewProfiles(g, "car")
+
+	if err := profiles.Set("bike", make([]float64, g.Edges-1)); !errors.Is(err, ErrProfileSize) {
+		t.Fatalf("expected ErrProfileSize, got %v", err)
+	}
+	weights := make([]float64, g.Edges)
+	weights[3] = -1
+	if err := profiles.Set("bike", weights); !errors.Is(err, ErrNegativeWeight) {
+		t.Fatalf("expected ErrNegativeWeight, got %v", err)
+	}
+	weights[3] = math.NaN()
+	if err := profiles.Set("bike", weights); !errors.Is(err, ErrNaNWeight) {
+		t.Fatalf("expected ErrNaNWeight, got %v", err)
+	}
+	if len(profiles.Names()) != 1 {
+		t.Fatalf("rejected profile was added: %v", profiles.Names())
+	}
+
+	if _, err := profiles.Graph("bike"); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("expected ErrUnknownProfile, got %v", err)
+	}
+	if _, err := profiles.Solver("bike", Options{}); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("expected ErrUnknownProfile, got %v", err)
+	}
+	if _, err := profiles.Pool("bike", 1, Options{}); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("expected ErrUnknownProfile, got %v", err)
+	}
+	if _, err := profiles.Transformation("bike"); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("expected ErrUnknownProfile, got %v", err)
+	}
+}
+
+func TestProfiles_FilteredParallelEdges(t *testing.T) {
+	// The lighter parallel edge in the topology is the heavier one in the
+	// profile, so a filtered query must not pick the edges in topology order.
+	g := NewGraph(3)
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(0, 1, 5)
+	g.AddEdge(1, 2, 1)
+	profiles := NewProfiles(g, "car")
+	if err := profiles.Set("bike", []float64{10, 2, 1}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	solver, err := profiles.Solver("bike", Options{Algorithm: AlgorithmBMSSP})
+	if err != nil {
+		t.Fatalf("Solver failed: %v", err)
+	}
+	allowAll := func(u, v, e int) bool { return true }
+	if d, _ := solver.Solve(0, 2); d != 3 {
+		t.Fatalf("Solve: got %f, want 3", d)
+	}
+	if d, _ := solver.SolveFiltered(0, 2, allowAll); d != 3 {
+		t.Fatalf("SolveFiltered: got %f, want 3", d)
+	}
+	noLight := func(u, v, e int) bool { return e != 1 }
+	if d, _ := solver.SolveFiltered(0, 2, noLight); d != 11 {
+		t.Fatalf("SolveFiltered without edge 1: got %f, want 11", d)
+	}
+}
+
+func TestProfiles_ReplacedProfile(t *testing.T) {
+	g := makeSparseGraph(150, 600, 247)
+	profiles := NewProfiles(g, "car")
+	old := make([]float64, g.Edges)
+	for e := range old {
+		old[e] = 1
+	}
+	if err := profiles.Set("bike", old); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	oldGraph, _ := profiles.Graph("bike")
+	// The solver builds its transformation only on its first query, after
+	// the profile has been replaced.
+	solver, err := profiles.Solver("bike", Options{Algorithm: AlgorithmBMSSP})
+	if err != nil {
+		t.Fatalf("Solver failed: %v", err)
+	}
+	replaced := make([]float64, g.Edges)
+	for e := range replaced {
+		replaced[e] = 100
+	}
+	if err := profiles.Set("bike", replaced); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	newGraph, _ := profiles.Graph("bike")
+
+	for v := 0; v < g.Vertices; v++ {
+		want, _ := DijkstraCSR(oldGraph, 0, v)
+		if got, _ := solver.Solve(0, v); got != want {
+			t.Fatalf("old solver: distance to %d is %f, want %f", v, got, want)
+		}
+	}
+	current, err := profiles.Solver("bike", Options{Algorithm: AlgorithmBMSSP})
+	if err != nil {
+		t.Fatalf("Solver failed: %v", err)
+	}
+	for v := 0; v < g.Vertices; v++ {
+		want, _ := DijkstraCSR(newGraph, 0, v)
+		if got, _ := current.Solve(0, v); got != want {
+			t.Fatalf("new solver: distance to %d is %f, want %f", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package bmssp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"math"
+	"slices"
+	"unsafe"
+)
+
+// Snapshot file layout. All integers are little-endian. The header is followed
+// by the payload, a sequence of 8-byte values:
+//
+//	offsets   [vertices+1]int64
+//	targets   [edges]int64
+//	weights   [edges]float64
+//	origToNew [origVertices]int64   (transformations only)
+//	newToOrig [vertices]int64       (transformations only)
+//
+// The checksum is the CRC-64/ECMA of the payload. Because the header is 64
+// bytes and every payload value is 8 bytes wide, each section is 8-byte aligned
+// in a memory-mapped file and can be used in place on little-endian 64-bit
+// platforms.
+const (
+	snapshotMagic      = "BMSSPSNP"
+	snapshotVersion    = 1
+	snapshotHeaderSize = 64
+
+	snapshotKindGraph          = 1
+	snapshotKindTransformation = 2
+
+	// snapshotChunkSize is the most ReadSnapshot allocates ahead of the data
+	// it has actually read, so a corrupt header cannot make it allocate the
+	// whole declared payload up front.
+	snapshotChunkSize = 1 << 20
+)
+
+// ErrSnapshotFormat is returned when a snapshot is truncated, has an unknown
+// magic number, kind or version, or fails its checksum.
+var ErrSnapshotFormat = errors.New("bmssp: invalid snapshot")
+
+var snapshotTable = crc64.MakeTable(crc64.ECMA)
+
+type snapshotHeader struct {
+	Kind         uint32
+	Vertices     uint64
+	Edges        uint64
+	OrigVertices uint64
+	Checksum     uint64
+}
+
+// Snapshot is a graph or a constant-degree transformation loaded from a binary
+// snapshot. Exactly one of Graph and Transformation is set; for
+// transformations, Transformation.Graph is nil and Transformation.CSR holds the
+// transformed graph. A snapshot opened with OpenSnapshot refers to read-only
+// mapped memory: its arrays must not be modified and must not be used after
+// Close.
+type Snapshot struct {
+	Graph          *CSRGraph
+	Transformation *Transformation
+
+	unmap func() error
+}
+
+// Close releases the memory mapping, if any.
+func (s *Snapshot) Close() error {
+	if s.unmap == nil {
+		return nil
+	}
+	unmap := s.unmap
+	s.unmap = nil
+	return unmap()
+}
+
+// WriteGraphSnapshot writes c in the binary snapshot format.
+func WriteGraphSnapshot(w io.Writer, c *CSRGraph) error {
+	return writeSnapshot(w, snapshotKindGraph, c, nil, nil)
+}
+
+// WriteTransformationSnapshot writes a constant-degree transformation in the
+// binary snapshot format, so it does not have to be rebuilt at start-up.
+func WriteTransformationSnapshot(w io.Writer, t *Transformation) error {
+	c := t.CSR
+	if c == nil {
+		c = NewCSRGraph(t.Graph)
+	}
+	return writeSnapshot(w, snapshotKindTransformation, c, t.OrigToNew, t.NewToOrig)
+}
+
+// ReadSnapshot reads a snapshot from r into memory and verifies its checksum.
+// The payload is read in chunks, so memory use is bounded by the size of the
+// input rather than by the sizes the header declares.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	header := make([]byte, snapshotHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: reading header: %v", ErrSnapshotFormat, err)
+	}
+	h, size, err := parseSnapshotHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := readSnapshotPayload(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSnapshot(h, payload, false)
+}
+
+// readSnapshotPayload reads size bytes from r, growing the buffer by at most
+// snapshotChunkSize at a time.
+func readSnapshotPayload(r io.Reader, size int) ([]byte, error) {
+	lr := io.LimitReader(r, int64(size))
+	payload := make([]byte, 0, min(size, snapshotChunkSize))
+	for len(payload) < size {
+		if len(payload) == cap(payload) {
+			payload = slices.Grow(payload, min(size-len(payload), max(len(payload), snapshotChunkSize)))
+		}
+		n, err := lr.Read(payload[len(payload):cap(payload)])
+		payload = payload[:len(payload)+n]
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading payload: %v", ErrSnapshotFormat, err)
+		}
+	}
+	if len(payload) < size {
+		return nil, fmt.Errorf("%w: reading payload: %v", ErrSnapshotFormat, io.ErrUnexpectedEOF)
+	}
+	return payload, nil
+}
+
+func writeSnapshot(w io.Writer, kind uint32, c *CSRGraph, origToNew, newToOrig []int) error {
+	hash := crc64.New(snapshotTable)
+	writePayload := func(out io.Writer) error {
+		bw := bufio.NewWriter(out)
+		buf := make([]byte, 8)
+		putInts := func(values []int) {
+			for _, v := range values {
+				binary.LittleEndian.PutUint64(buf, uint64(v))
+				bw.Write(buf)
+			}
+		}
+		putInts(c.Offsets)
+		putInts(c.Targets)
+		for _, v := range c.Weights {
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(v))
+			bw.Write(buf)
+		}
+		if kind == snapshotKindTransformation {
+			putInts(origToNew)
+			putInts(newToOrig)
+		}
+		return bw.Flush()
+	}
+
+	// The checksum covers the payload and is stored in the header, so the
+	// payload is encoded twice rather than buffered.
+	if err := writePayload(hash); err != nil {
+		return err
+	}
+
+	header := make([]byte, snapshotHeaderSize)
+	copy(header, snapshotMagic)
+	binary.LittleEndian.PutUint32(header[8:], snapshotVersion)
+	binary.LittleEndian.PutUint32(header[12:], kind)
+	binary.LittleEndian.PutUint64(header[16:], uint64(c.Vertices))
+	binary.LittleEndian.PutUint64(header[24:], uint64(c.Edges))
+	binary.LittleEndian.PutUint64(header[32:], uint64(len(origToNew)))
+	binary.LittleEndian.PutUint64(header[40:], hash.Sum64())
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	return writePayload(w)
+}
+
+// parseSnapshotHeader validates the header and returns it together with the
+// payload size in bytes.
+func parseSnapshotHeader(header []byte) (snapshotHeader, int, error) {
+	var h snapshotHeader
+	if len(header) < snapshotHeaderSize || string(header[:8]) != snapshotMagic {
+		return h, 0, fmt.Errorf("%w: bad magic", ErrSnapshotFormat)
+	}
+	if version := binary.LittleEndian.Uint32(header[8:]); version != snapshotVersion {
+		return h, 0, fmt.Errorf("%w: unsupported version %d", ErrSnapshotFormat, version)
+	}
+	h.Kind = binary.LittleEndian.Uint32(header[12:])
+	h.Vertices = binary.LittleEndian.Uint64(header[16:])
+	h.Edges = binary.LittleEndian.Uint64(header[24:])
+	h.OrigVertices = binary.LittleEndian.Uint64(header[32:])
+	h.Checksum = binary.LittleEndian.Uint64(header[40:])
+
+	if h.Kind != snapshotKindGraph && h.Kind != snapshotKindTransformation {
+		return h, 0, fmt.Errorf("%w: unknown kind %d", ErrSnapshotFormat, h.Kind)
+	}
+	// Every original vertex owns at least one vertex of its transformation,
+	// and plain graphs have no vertex mapping.
+	if h.Kind == snapshotKindGraph && h.OrigVertices != 0 || h.OrigVertices > h.Vertices {
+		return h, 0, fmt.Errorf("%w: inconsistent vertex counts", ErrSnapshotFormat)
+	}
+	limit := uint64(maxInt / 8)
+	if h.Vertices >= limit || h.Edges >= limit || h.OrigVertices >= limit {
+		return h, 0, fmt.Errorf("%w: payload too large for this platform", ErrSnapshotFormat)
+	}
+	words := h.Vertices + 1 + 2*h.Edges
+	if h.Kind == snapshotKindTransformation {
+		words += h.OrigVertices + h.Vertices
+	}
+	if words >= limit {
+		return h, 0, fmt.Errorf("%w: payload too large for this platform", ErrSnapshotFormat)
+	}
+	return h, int(words * 8), nil
+}
+
+// decodeSnapshot verifies the checksum of payload and builds the snapshot. With
+// inPlace set, the arrays alias payload when the platform layout allows it.
+func decodeSnapshot(h snapshotHeader, payload []byte, inPlace bool) (*Snapshot, error) {
+	if crc64.Checksum(payload, snapshotTable) != h.Checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrSnapshotFormat)
+	}
+
+	n, m := int(h.Vertices), int(h.Edges)
+	take := func(count int) []byte {
+		section := payload[:count*8]
+		payload = payload[count*8:]
+		return section
+	}
+	ints := func(count int) []int { return decodeInts(take(count), inPlace) }
+
+	c := &CSRGraph{Vertices: n, Edges: m}
+	c.Offsets = ints(n + 1)
+	c.Targets = ints(m)
+	c.Weights = decodeFloats(take(m), inPlace)
+	if err := checkSnapshotCSR(c); err != nil {
+		return nil, err
+	}
+
+	if h.Kind == snapshotKindGraph {
+		return &Snapshot{Graph: c}, nil
+	}
+	t := &Transformation{CSR: c}
+	t.OrigToNew = ints(int(h.OrigVertices))
+	t.NewToOrig = ints(n)
+	for _, v := range t.OrigToNew {
+		if v < 0 || v >= n {
+			return nil, fmt.Errorf("%w: vertex mapping out of range", ErrSnapshotFormat)
+		}
+	}
+	for _, v := range t.NewToOrig {
+		if v < 0 || v >= len(t.OrigToNew) {
+			return nil, fmt.Errorf("%w: vertex mapping out of range", ErrSnapshotFormat)
+		}
+	}
+	return &Snapshot{Transformation: t}, nil
+}
+
+// checkSnapshotCSR guards the solver against a well-formed but inconsistent
+// snapshot, which would otherwise cause out-of-range accesses.
+func checkSnapshotCSR(c *CSRGraph) error {
+	if c.Offsets[0] != 0 || c.Offsets[c.Vertices] != c.Edges {
+		return fmt.Errorf("%w: inconsistent offsets", ErrSnapshotFormat)
+	}
+	for u := 0; u < c.Vertices; u++ {
+		if c.Offsets[u] > c.Offsets[u+1] {
+			return fmt.Errorf("%w: inconsistent offsets", ErrSnapshotFormat)
+		}
+	}
+	for _, v := range c.Targets {
+		if v < 0 || v >= c.Vertices {
+			return fmt.Errorf("%w: edge target out of range", ErrSnapshotFormat)
+		}
+	}
+	return nil
+}
+
+// nativeLayout reports whether int and float64 values are stored in memory
+// exactly as in the snapshot format, i.e. as 8-byte little-endian values.
+func nativeLayout() bool {
+	x := uint16(1)
+	return unsafe.Sizeof(int(0)) == 8 && *(*byte)(unsafe.Pointer(&x)) == 1
+}
+
+func aligned(b []byte) bool {
+	return len(b) == 0 || uintptr(unsafe.Pointer(&b[0]))%8 == 0
+}
+
+func decodeInts(b []byte, inPlace bool) []int {
+	count := len(b) / 8
+	if inPlace && count > 0 && nativeLayout() && aligned(b) {
+		return unsafe.Slice((*int)(unsafe.Pointer(&b[0])), count)
+	}
+	out := make([]int, count)
+	for i := range out {
+		out[i] = int(int64(binary.LittleEndian.Uint64(b[i*8:])))
+	}
+	return out
+}
+
+func decodeFloats(b []byte, inPlace bool) []float64 {
+	count := len(b) / 8
+	if inPlace && count > 0 && nativeLayout() && aligned(b) {
+		return unsafe.Slice((*float64)(unsafe.Pointer(&b[0])), count)
+	}
+	out := make([]float64, count)
+	for i := range out {
+		out[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[i*8:]))
+	}
+	return out
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package bmssp
+
+import (
+	"bufio"
+	"os"
+)
+
+// OpenSnapshot reads the snapshot at path and verifies its checksum. Memory
+// mapping is only available on Unix systems; elsewhere the file is read into
+// memory.
+func OpenSnapshot(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSnapshot(bufio.NewReader(f))
+}
@@ -0,0 +1,135 @@
+package bmssp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshot_GraphRoundTrip(t *testing.T) {
+	g := makeSparseGraph(50, 200, 23)
+	c := NewCSRGraph(g)
+
+	var buf bytes.Buffer
+	if err := WriteGraphSnapshot(&buf, c); err != nil {
+		t.Fatalf("WriteGraphSnapshot failed: %v", err)
+	}
+	snap, err := ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadSnapshot failed: %v", err)
+	}
+	assertSameCSR(t, c, snap.Graph)
+
+	path := filepath.Join(t.TempDir(), "graph.snap")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	mapped, err := OpenSnapshot(path)
+	if err != nil {
+		t.Fatalf("OpenSnapshot failed: %v", err)
+	}
+	defer mapped.Close()
+	assertSameCSR(t, c, mapped.Graph)
+
+	solver := NewCSRSolver(mapped.Graph)
+	solver.ForceBMSSP = true
+	for target := 0; target < g.Vertices; target += 7 {
+		want, _ := Dijkstra(g, 0, target)
+		got, _ := solver.Solve(0, target)
+		if want != got && !(math.IsInf(want, 1) && math.IsInf(got, 1)) {
+			t.Fatalf("distance mismatch on mapped graph: %f vs %f", got, want)
+		}
+	}
+}
+
+func TestSnapshot_TransformationRoundTrip(t *testing.T) {
+	g := makeSparseGraph(40, 160, 29)
+	transform := NewConstantDegreeGraph(g)
+
+	var buf bytes.Buffer
+	if err := WriteTransformationSnapshot(&buf, transform); err != nil {
+		t.Fatalf("WriteTransformationSnapshot failed: %v", err)
+	}
+	snap, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("ReadSnapshot failed: %v", err)
+	}
+	if snap.Graph != nil || snap.Transformation == nil {
+		t.Fatalf("expected a transformation snapshot")
+	}
+	loaded := snap.Transformation
+	assertSameCSR(t, transform.CSR, loaded.CSR)
+	for v := range transform.OrigToNew {
+		if loaded.OrigToNew[v] != transform.OrigToNew[v] {
+			t.Fatalf("OrigToNew mismatch at %d", v)
+		}
+	}
+	for v := range transform.NewToOrig {
+		if loaded.NewToOrig[v] != transform.NewToOrig[v] {
+			t.Fatalf("NewToOrig mismatch at %d", v)
+		}
+	}
+}
+
+func TestSnapshot_Corruption(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGraphSnapshot(&buf, NewCSRGraph(makeSparseGraph(10, 30, 31))); err != nil {
+		t.Fatalf("WriteGraphSnapshot failed: %v", err)
+	}
+	data := buf.Bytes()
+
+	flipped := append([]byte(nil), data...)
+	flipped[len(flipped)-1] ^= 0xff
+	if _, err := ReadSnapshot(bytes.NewReader(flipped)); !errors.Is(err, ErrSnapshotFormat) {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+
+	if _, err := ReadSnapshot(bytes.NewReader(data[:len(data)-8])); !errors.Is(err, ErrSnapshotFormat) {
+		t.Fatalf("expected truncation error, got %v", err)
+	}
+
+	badMagic := append([]byte(nil), data...)
+	badMagic[0] = 'X'
+	if _, err := ReadSnapshot(bytes.NewReader(badMagic)); !errors.Is(err, ErrSnapshotFormat) {
+		t.Fatalf("expected magic error, got %v", err)
+	}
+
+	// A header declaring a huge graph must fail on the missing payload rather
+	// than allocate it.
+	huge := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(huge[16:], 1<<28)
+	binary.LittleEndian.PutUint64(huge[24:], 1<<28)
+	if _, err := ReadSnapshot(bytes.NewReader(huge)); !errors.Is(err, ErrSnapshotFormat) {
+		t.Fatalf("expected truncation error for huge header, got %v", err)
+	}
+
+	mapped := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(mapped[32:], 5)
+	if _, err := ReadSnapshot(bytes.NewReader(mapped)); !errors.Is(err, ErrSnapshotFormat) {
+		t.Fatalf("expected vertex count error, got %v", err)
+	}
+}
+
+func assertSameCSR(t *testing.T, want, got *CSRGraph) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("expected graph, got nil")
+	}
+	if got.Vertices != want.Vertices || got.Edges != want.Edges {
+		t.Fatalf("size mismatch: (%d,%d) vs (%d,%d)", got.Vertices, got.Edges, want.Vertices, want.Edges)
+	}
+	for i := range want.Offsets {
+		if got.Offsets[i] != want.Offsets[i] {
+			t.Fatalf("offset mismatch at %d", i)
+		}
+	}
+	for i := range want.Targets {
+		if got.Targets[i] != want.Targets[i] || got.Weights[i] != want.Weights[i] {
+			t.Fatalf("edge mismatch at %d", i)
+		}
+	}
+}
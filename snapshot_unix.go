@@ -0,0 +1,54 @@
+//go:build unix
+
+package bmssp
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// OpenSnapshot memory-maps the snapshot at path read-only and verifies its
+// checksum. On little-endian 64-bit platforms the graph arrays point directly
+// into the mapping, so start-up does not copy the graph and processes that open
+// the same file share its pages. Call Close when the snapshot is no longer
+// used.
+func OpenSnapshot(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size < snapshotHeaderSize {
+		return nil, fmt.Errorf("%w: file too small", ErrSnapshotFormat)
+	}
+	if size > int64(maxInt) {
+		return nil, fmt.Errorf("%w: file too large for this platform", ErrSnapshotFormat)
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("bmssp: mmap %s: %w", path, err)
+	}
+
+	h, payloadSize, err := parseSnapshotHeader(data)
+	if err == nil && len(data)-snapshotHeaderSize < payloadSize {
+		err = fmt.Errorf("%w: truncated payload", ErrSnapshotFormat)
+	}
+	var snap *Snapshot
+	if err == nil {
+		snap, err = decodeSnapshot(h, data[snapshotHeaderSize:snapshotHeaderSize+payloadSize], true)
+	}
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, err
+	}
+	snap.unmap = func() error { return syscall.Munmap(data) }
+	return snap, nil
+}
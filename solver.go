@@ -2,7 +2,10 @@ package bmssp
 
 import (
 	"container/heap"
+	"context"
+	"fmt"
 	"math"
+	"time"
 )
 
 const (
@@ -10,69 +13,391 @@ const (
 	tExponent = 2.0 / 3.0
 )
 
-type Solver struct {
-	Graph        *Graph
+// SolverOf answers shortest-path queries with BMSSP over graphs with edge
+// weights of type W.
+type SolverOf[W Weight] struct {
+	Graph        *GraphOf[W]
 	N            int
 	K            int
 	T            int
 	Levels       int
-	Distances    []float64
+	Distances    []W
 	Hops         []int
 	Predecessors []int
-	ForceBMSSP   bool
+	Options      Options
+	Stats        *Stats
+
+	// Deprecated: set Options.Algorithm to AlgorithmBMSSP instead.
+	ForceBMSSP bool
+
+	// csr is the graph the relaxation loops run on. Solvers created with
+	// NewCSRSolver set it once; for a Graph it is refreshed from Graph.CSR
+	// before each run.
+	csr *CSRGraphOf[W]
+
+	transform    *TransformationOf[W]
+	internal     *SolverOf[W]
+	transformKey transformationKey
+	// build, if set, supplies the constant-degree transformation instead of
+	// NewConstantDegreeCSRContext, e.g. one shared between weight profiles.
+	build func(ctx context.Context) (*TransformationOf[W], error)
+
+	// ctx and err belong to the search in progress: err is set once ctx is
+	// observed to be done, after which every loop unwinds. overflowed records
+	// that a relaxation was skipped because its sum did not fit W.
+	ctx        context.Context
+	err        error
+	overflowed bool
+
+	// filter restricts the edges of the current query, if any.
+	filter *transformedFilter[W]
+
+	// inf is the distance of unreachable vertices, see infinity.
+	inf W
 }
 
-func NewSolver(graph *Graph) *Solver {
-	n := graph.Vertices
-	k, t := computeParameters(n)
-	levels := computeLevels(n, t)
+// Solver is the solver for graphs with float64 weights.
+type Solver = SolverOf[float64]
 
-	return &Solver{
-		Graph:        graph,
+func NewSolver[W Weight](graph *GraphOf[W]) *SolverOf[W] {
+	s := newSolver[W](graph.Vertices)
+	s.Graph = graph
+	return s
+}
+
+// NewCSRSolver creates a solver over an immutable compressed graph. The solver
+// never copies c, so a single CSRGraph can back any number of solvers.
+func NewCSRSolver[W Weight](c *CSRGraphOf[W]) *SolverOf[W] {
+	s := newSolver[W](c.Vertices)
+	s.csr = c
+	return s
+}
+
+// TryNewSolver is like NewSolver but first validates the graph, so that
+// negative or NaN weights are reported instead of producing wrong distances.
+func TryNewSolver[W Weight](graph *GraphOf[W]) (*SolverOf[W], error) {
+	if err := graph.Validate(); err != nil {
+		return nil, err
+	}
+	return NewSolver(graph), nil
+}
+
+// TryNewCSRSolver is like NewCSRSolver but first validates the graph.
+func TryNewCSRSolver[W Weight](c *CSRGraphOf[W]) (*SolverOf[W], error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return NewCSRSolver(c), nil
+}
+
+func newSolver[W Weight](n int) *SolverOf[W] {
+	s := &SolverOf[W]{
 		N:            n,
-		K:            k,
-		T:            t,
-		Levels:       levels,
-		Distances:    make([]float64, n),
+		Distances:    make([]W, n),
 		Hops:         make([]int, n),
 		Predecessors: make([]int, n),
+		inf:          infinity[W](),
 	}
+	s.applyParameters()
+	return s
 }
 
-func (s *Solver) Solve(source, goal int) (float64, []int) {
-	if source < 0 || source >= s.N || goal < 0 || goal >= s.N {
-		return math.Inf(1), nil
+func (s *SolverOf[W]) Solve(source, goal int) (W, []int) {
+	dist, path, _ := s.SolveContext(context.Background(), source, goal)
+	return dist, path
+}
+
+// TrySolve is like Solve but reports an invalid source or goal with
+// ErrVertexOutOfRange instead of returning +Inf. With integer weights, a
+// distance that does not fit W is reported with ErrWeightOverflow.
+func (s *SolverOf[W]) TrySolve(source, goal int) (W, []int, error) {
+	return s.SolveContext(context.Background(), source, goal)
+}
+
+// SolveContext is like TrySolve but gives up once ctx is done and returns
+// ctx.Err(). Cancellation is checked on every pull of the BMSSP frontier, every
+// step of the base case and while building the transformation.
+func (s *SolverOf[W]) SolveContext(ctx context.Context, source, goal int) (W, []int, error) {
+	return s.SolveFilteredContext(ctx, source, goal, nil)
+}
+
+// SolveFiltered is like Solve but only uses the edges allow accepts. The filter
+// applies to this query alone and is honoured by BMSSP and the Dijkstra
+// fallback alike.
+func (s *SolverOf[W]) SolveFiltered(source, goal int, allow EdgeFilter) (W, []int) {
+	dist, path, _ := s.SolveFilteredContext(context.Background(), source, goal, allow)
+	return dist, path
+}
+
+// SolveFilteredContext is like SolveFiltered but reports invalid vertices and
+// gives up once ctx is done, like SolveContext. A nil allow accepts every
+// edge.
+func (s *SolverOf[W]) SolveFilteredContext(ctx context.Context, source, goal int, allow EdgeFilter) (W, []int, error) {
+	if err := checkVertex(source, s.N); err != nil {
+		return s.inf, nil, err
+	}
+	if err := checkVertex(goal, s.N); err != nil {
+		return s.inf, nil, err
 	}
 
-	if s.N < 1000 && !s.ForceBMSSP {
-		return Dijkstra(s.Graph, source, goal)
+	if s.useDijkstra() {
+		s.Stats.fallback()
+		c := s.adjacency()
+		if allow != nil {
+			return dijkstraFiltered(ctx, c, source, goal, allow)
+		}
+		if s.Options.BidirectionalFallback {
+			return bidirectionalSearch(ctx, c, c.Reverse(), source, goal)
+		}
+		return DijkstraCSRContext(ctx, c, source, goal)
 	}
 
-	transform := NewConstantDegreeGraph(s.Graph)
-	internal := NewSolver(transform.Graph)
-	dist, path := internal.solveBMSSP(transform.OrigToNew[source], transform.OrigToNew[goal])
-	if math.IsInf(dist, 1) || path == nil {
-		return math.Inf(1), nil
+	transform, internal, err := s.transformed(ctx)
+	if err != nil {
+		return s.inf, nil, err
+	}
+	if allow != nil {
+		internal.filter = newTransformedFilter(transform, s.adjacency(), allow)
+		defer func() {
+			internal.filter = nil
+		}()
+	}
+	dist, path, err := internal.solveBMSSP(ctx, transform.OrigToNew[source], transform.OrigToNew[goal])
+	if err != nil {
+		return s.inf, nil, err
+	}
+	if dist == s.inf || path == nil {
+		if internal.overflowed {
+			sources := []SourceOf[W]{{Vertex: source}}
+			err := checkOverflow(s.adjacency(), sources, allow, func(v int) bool { return v == goal })
+			return s.inf, nil, err
+		}
+		return s.inf, nil, nil
 	}
 	mapped := transform.MapPath(path)
 	if len(mapped) == 0 {
-		return math.Inf(1), nil
+		return s.inf, nil, nil
 	}
-	return dist, mapped
+	return dist, mapped, nil
 }
 
-func (s *Solver) solveBMSSP(source, goal int) (float64, []int) {
-	s.resetState()
-	s.Distances[source] = 0
-	s.Hops[source] = 0
+// SolveAll computes shortest paths from source to every vertex of the graph and
+// returns them as a tree. A single call answers any number of destination
+// lookups. It returns nil if source is not a vertex of the graph.
+func (s *SolverOf[W]) SolveAll(source int) *ShortestPathTreeOf[W] {
+	tree, _ := s.SolveAllContext(context.Background(), source)
+	return tree
+}
 
-	s.bmssp(s.Levels, infLabel(), []int{source})
+// TrySolveAll is like SolveAll but reports an invalid source with
+// ErrVertexOutOfRange instead of returning nil.
+func (s *SolverOf[W]) TrySolveAll(source int) (*ShortestPathTreeOf[W], error) {
+	return s.SolveAllContext(context.Background(), source)
+}
 
-	if math.IsInf(s.Distances[goal], 1) {
-		return math.Inf(1), nil
+// SolveAllContext is like TrySolveAll but gives up once ctx is done and returns
+// ctx.Err().
+func (s *SolverOf[W]) SolveAllContext(ctx context.Context, source int) (*ShortestPathTreeOf[W], error) {
+	if err := checkVertex(source, s.N); err != nil {
+		return nil, err
+	}
+	dist, prev, err := s.search(ctx, []SourceOf[W]{{Vertex: source}}, s.inf, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ShortestPathTreeOf[W]{Source: source, Distances: dist, Predecessors: prev}, nil
+}
+
+// search computes distances and predecessors of the original vertices from the
+// given sources for every vertex closer than bound, using only the edges allow
+// accepts. Vertices at distance bound or beyond are reported as unreachable.
+func (s *SolverOf[W]) search(ctx context.Context, sources []SourceOf[W], bound W, allow EdgeFilter) ([]W, []int, error) {
+	for _, src := range sources {
+		if err := checkVertex(src.Vertex, s.N); err != nil {
+			return nil, nil, err
+		}
+		if isNaN(src.Offset) {
+			return nil, nil, fmt.Errorf("%w: offset of source %d", ErrNaNDistance, src.Vertex)
+		}
+	}
+	if isNaN(bound) {
+		return nil, nil, fmt.Errorf("%w: bound", ErrNaNDistance)
+	}
+
+	var dist []W
+	var prev []int
+	overflowed := false
+	if s.useDijkstra() {
+		s.Stats.fallback()
+		var err error
+		dist, prev, err = dijkstraSearch(ctx, s.adjacency(), sources, -1, bound, allow)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		transform, internal, err := s.transformed(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if allow != nil {
+			internal.filter = newTransformedFilter(transform, s.adjacency(), allow)
+			defer func() {
+				internal.filter = nil
+			}()
+		}
+		mapped := make([]SourceOf[W], len(sources))
+		for i, src := range sources {
+			mapped[i] = SourceOf[W]{Vertex: transform.OrigToNew[src.Vertex], Offset: src.Offset}
+		}
+		complete, err := internal.runBMSSP(ctx, mapped, distanceBound(bound))
+		if err != nil {
+			return nil, nil, err
+		}
+		overflowed = internal.overflowed
+		inside := make([]bool, internal.N)
+		for _, v := range complete {
+			inside[v] = true
+		}
+		dist, prev = transform.MapTree(internal.Distances, internal.Predecessors)
+		for v := range dist {
+			if !inside[transform.OrigToNew[v]] {
+				dist[v] = s.inf
+			}
+		}
+	}
+
+	for v := range dist {
+		if !(dist[v] < bound) {
+			dist[v] = s.inf
+			prev[v] = -1
+		}
+	}
+	if overflowed && bound == s.inf {
+		unreached := func(v int) bool { return dist[v] == s.inf }
+		if err := checkOverflow(s.adjacency(), sources, allow, unreached); err != nil {
+			return nil, nil, err
+		}
+	}
+	return dist, prev, nil
+}
+
+// Invalidate discards the cached constant-degree transformation. Mutations made
+// through Graph methods are detected automatically; call Invalidate after
+// editing Graph.Adj directly.
+func (s *SolverOf[W]) Invalidate() {
+	if s.Graph != nil {
+		s.Graph.Invalidate()
+	}
+	s.transform = nil
+	s.internal = nil
+}
+
+// adjacency returns the compressed form of the graph the solver answers
+// queries on.
+func (s *SolverOf[W]) adjacency() *CSRGraphOf[W] {
+	if s.Graph != nil {
+		return s.Graph.CSR()
+	}
+	return s.csr
+}
+
+// transformed returns the constant-degree transformation of the graph together
+// with a solver over it. Both are built on first use and rebuilt only when the
+// graph or Options.DisableTransformation has changed since. With the
+// transformation disabled, the identity mapping is returned instead.
+func (s *SolverOf[W]) transformed(ctx context.Context) (*TransformationOf[W], *SolverOf[W], error) {
+	key := transformationKey{identity: s.Options.DisableTransformation}
+	if s.Graph != nil {
+		key.version = s.Graph.version
+	}
+	if s.transform == nil || s.transformKey != key {
+		var transform *TransformationOf[W]
+		if key.identity {
+			transform = identityTransformation(s.adjacency())
+		} else {
+			start := time.Now()
+			var err error
+			if s.build != nil {
+				transform, err = s.build(ctx)
+			} else {
+				transform, err = NewConstantDegreeCSRContext(ctx, s.adjacency())
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			s.Stats.transformation(time.Since(start))
+		}
+		s.transform = transform
+		s.internal = NewCSRSolver(transform.CSR)
+		s.transformKey = key
+	}
+	s.internal.Options = s.Options
+	s.internal.applyParameters()
+	s.internal.Stats = s.Stats
+	return s.transform, s.internal, nil
+}
+
+func (s *SolverOf[W]) solveBMSSP(ctx context.Context, source, goal int) (W, []int, error) {
+	if _, err := s.runBMSSP(ctx, []SourceOf[W]{{Vertex: source}}, infLabel[W]()); err != nil {
+		return s.inf, nil, err
+	}
+
+	if s.Distances[goal] == s.inf {
+		return s.inf, nil, nil
+	}
+
+	return s.Distances[goal], s.reconstructPath(source, goal), nil
+}
+
+// runBMSSP fills Distances, Hops and Predecessors from the given sources for
+// every vertex whose label is below bound and returns those vertices. Each
+// source starts at its offset; when a vertex is listed more than once the
+// smallest offset wins. If ctx is done before the search completes, the state
+// is left partial and ctx.Err() is returned.
+func (s *SolverOf[W]) runBMSSP(ctx context.Context, sources []SourceOf[W], bound LabelOf[W]) ([]int, error) {
+	s.csr = s.adjacency()
+	s.ctx = ctx
+	s.err = nil
+	s.overflowed = false
+	defer func() {
+		s.ctx = nil
+	}()
+
+	s.resetState()
+	frontier := make([]int, 0, len(sources))
+	for _, src := range sources {
+		if src.Offset == s.inf {
+			continue
+		}
+		if s.Distances[src.Vertex] == s.inf {
+			frontier = append(frontier, src.Vertex)
+		}
+		if src.Offset < s.Distances[src.Vertex] {
+			s.Distances[src.Vertex] = src.Offset
+			s.Hops[src.Vertex] = 0
+		}
 	}
 
-	return s.Distances[goal], s.reconstructPath(source, goal)
+	_, complete := s.bmssp(s.Levels, bound, frontier)
+	return complete, s.err
+}
+
+// interrupted reports whether the running search should stop, recording the
+// context error the first time it observes cancellation.
+func (s *SolverOf[W]) interrupted() bool {
+	if s.err != nil {
+		return true
+	}
+	if s.ctx == nil {
+		return false
+	}
+	select {
+	case <-s.ctx.Done():
+		s.err = s.ctx.Err()
+		return true
+	default:
+		return false
+	}
 }
 
 func computeParameters(n int) (int, int) {
@@ -101,29 +426,31 @@ func computeLevels(n, t int) int {
 	return int(math.Ceil(math.Log2(float64(n)) / float64(t)))
 }
 
-func (s *Solver) resetState() {
+func (s *SolverOf[W]) resetState() {
 	for i := 0; i < s.N; i++ {
-		s.Distances[i] = math.Inf(1)
+		s.Distances[i] = s.inf
 		s.Hops[i] = maxInt
 		s.Predecessors[i] = -1
 	}
 }
 
-func (s *Solver) label(v int) Label {
-	return Label{
+func (s *SolverOf[W]) label(v int) LabelOf[W] {
+	return LabelOf[W]{
 		Dist:   s.Distances[v],
 		Hops:   s.Hops[v],
 		Vertex: v,
 	}
 }
 
-func (s *Solver) bmssp(level int, bound Label, frontier []int) (Label, []int) {
+func (s *SolverOf[W]) bmssp(level int, bound LabelOf[W], frontier []int) (LabelOf[W], []int) {
 	if len(frontier) == 0 {
 		return bound, nil
 	}
 
 	if level <= 0 {
-		return s.baseCase(bound, frontier)
+		resultBound, result := s.baseCase(bound, frontier)
+		recordCall(s.Stats, level, s.Levels-level, bound.Dist, resultBound.Dist, len(frontier), len(result))
+		return resultBound, result
 	}
 
 	pivots, workingSet := s.findPivots(bound, frontier)
@@ -140,7 +467,11 @@ func (s *Solver) bmssp(level int, bound Label, frontier []int) (Label, []int) {
 	lastBound := bound
 
 	for len(uSet) < limit && !ds.IsEmpty() {
+		if s.interrupted() {
+			return bound, uList
+		}
 		subBound, subset := ds.Pull()
+		s.Stats.pull()
 		if len(subset) == 0 {
 			continue
 		}
@@ -149,18 +480,19 @@ func (s *Solver) bmssp(level int, bound Label, frontier []int) (Label, []int) {
 		lastBound = subPrime
 		addUnique(uSet, &uList, subResult)
 
-		batch := make([]frontierItem, 0)
+		batch := make([]frontierItem[W], 0)
 		for _, u := range subResult {
-			for _, edge := range s.Graph.Adj[u] {
-				v := edge.To
-				if !s.relaxEdge(u, v, edge.Weight) {
+			for e := s.csr.Offsets[u]; e < s.csr.Offsets[u+1]; e++ {
+				v := s.csr.Targets[e]
+				w, ok := s.edgeWeight(u, e)
+				if !ok || !s.relaxEdge(u, v, w) {
 					continue
 				}
 				label := s.label(v)
 				if labelInRange(label, subBound, bound) {
 					ds.Insert(v, label)
 				} else if labelInRange(label, subPrime, subBound) {
-					batch = append(batch, frontierItem{Vertex: v, Label: label})
+					batch = append(batch, frontierItem[W]{Vertex: v, Label: label})
 				}
 			}
 		}
@@ -168,11 +500,12 @@ func (s *Solver) bmssp(level int, bound Label, frontier []int) (Label, []int) {
 		for _, v := range subset {
 			label := s.label(v)
 			if labelInRange(label, subPrime, subBound) {
-				batch = append(batch, frontierItem{Vertex: v, Label: label})
+				batch = append(batch, frontierItem[W]{Vertex: v, Label: label})
 			}
 		}
 
 		if len(batch) > 0 {
+			s.Stats.batchPrepend(len(batch))
 			ds.BatchPrepend(batch)
 		}
 	}
@@ -192,21 +525,22 @@ func (s *Solver) bmssp(level int, bound Label, frontier []int) (Label, []int) {
 		}
 	}
 
+	recordCall(s.Stats, level, s.Levels-level, bound.Dist, resultBound.Dist, len(frontier), len(uList))
 	return resultBound, uList
 }
 
-func (s *Solver) baseCase(bound Label, sources []int) (Label, []int) {
+func (s *SolverOf[W]) baseCase(bound LabelOf[W], sources []int) (LabelOf[W], []int) {
 	if len(sources) == 0 {
 		return bound, nil
 	}
 
-	pq := &labelHeap{}
+	pq := &labelHeap[W]{}
 	heap.Init(pq)
 
 	for _, start := range sources {
 		label := s.label(start)
 		if label.Less(bound) {
-			heap.Push(pq, frontierItem{Vertex: start, Label: label})
+			heap.Push(pq, frontierItem[W]{Vertex: start, Label: label})
 		}
 	}
 
@@ -214,7 +548,10 @@ func (s *Solver) baseCase(bound Label, sources []int) (Label, []int) {
 	visited := make([]int, 0, s.K+1)
 
 	for pq.Len() > 0 && len(visited) < s.K+1 {
-		item := heap.Pop(pq).(frontierItem)
+		if s.interrupted() {
+			return bound, visited
+		}
+		item := heap.Pop(pq).(frontierItem[W])
 		u := item.Vertex
 		if !item.Label.Equal(s.label(u)) {
 			continue
@@ -225,14 +562,15 @@ func (s *Solver) baseCase(bound Label, sources []int) (Label, []int) {
 		visitedSet[u] = struct{}{}
 		visited = append(visited, u)
 
-		for _, edge := range s.Graph.Adj[u] {
-			v := edge.To
-			if !s.relaxEdge(u, v, edge.Weight) {
+		for e := s.csr.Offsets[u]; e < s.csr.Offsets[u+1]; e++ {
+			v := s.csr.Targets[e]
+			w, ok := s.edgeWeight(u, e)
+			if !ok || !s.relaxEdge(u, v, w) {
 				continue
 			}
 			label := s.label(v)
 			if label.Less(bound) {
-				heap.Push(pq, frontierItem{Vertex: v, Label: label})
+				heap.Push(pq, frontierItem[W]{Vertex: v, Label: label})
 			}
 		}
 	}
@@ -259,7 +597,7 @@ func (s *Solver) baseCase(bound Label, sources []int) (Label, []int) {
 	return maxLabel, result
 }
 
-func (s *Solver) findPivots(bound Label, frontier []int) ([]int, []int) {
+func (s *SolverOf[W]) findPivots(bound LabelOf[W], frontier []int) ([]int, []int) {
 	if len(frontier) == 0 {
 		return nil, nil
 	}
@@ -287,9 +625,10 @@ func (s *Solver) findPivots(bound Label, frontier []int) ([]int, []int) {
 			if !s.label(u).Less(bound) {
 				continue
 			}
-			for _, edge := range s.Graph.Adj[u] {
-				v := edge.To
-				if !s.relaxEdge(u, v, edge.Weight) {
+			for e := s.csr.Offsets[u]; e < s.csr.Offsets[u+1]; e++ {
+				v := s.csr.Targets[e]
+				w, ok := s.edgeWeight(u, e)
+				if !ok || !s.relaxEdge(u, v, w) {
 					continue
 				}
 				if s.label(v).Less(bound) {
@@ -302,6 +641,7 @@ func (s *Solver) findPivots(bound Label, frontier []int) ([]int, []int) {
 		}
 
 		if len(workingSet) > limit {
+			s.Stats.pivots(len(frontier), true)
 			return frontier, setToSlice(workingSet)
 		}
 
@@ -346,15 +686,35 @@ func (s *Solver) findPivots(bound Label, frontier []int) ([]int, []int) {
 		}
 	}
 
+	s.Stats.pivots(len(pivots), false)
 	return pivots, setToSlice(workingSet)
 }
 
-func (s *Solver) relaxEdge(u, v int, weight float64) bool {
-	if math.IsInf(s.Distances[u], 1) {
+// edgeWeight returns the weight of the edge e out of u, or false if the filter
+// of the current query excludes it.
+func (s *SolverOf[W]) edgeWeight(u, e int) (W, bool) {
+	if s.filter == nil {
+		return s.csr.Weights[e], true
+	}
+	return s.filter.weight(u, e)
+}
+
+func (s *SolverOf[W]) relaxEdge(u, v int, weight W) bool {
+	relaxed := s.relax(u, v, weight)
+	s.Stats.relaxation(relaxed)
+	return relaxed
+}
+
+func (s *SolverOf[W]) relax(u, v int, weight W) bool {
+	if s.Distances[u] == s.inf {
 		return false
 	}
 
-	newDist := s.Distances[u] + weight
+	newDist, ok := addWeights(s.Distances[u], weight, s.inf)
+	if !ok {
+		s.overflowed = true
+		return false
+	}
 	newHops := s.Hops[u]
 	if newHops < maxInt {
 		newHops++
@@ -393,7 +753,7 @@ func (s *Solver) relaxEdge(u, v int, weight float64) bool {
 	return false
 }
 
-func (s *Solver) blockSize(level int) int {
+func (s *SolverOf[W]) blockSize(level int) int {
 	if level <= 0 {
 		return 1
 	}
@@ -401,7 +761,7 @@ func (s *Solver) blockSize(level int) int {
 	return pow2(exp)
 }
 
-func (s *Solver) threshold(level int) int {
+func (s *SolverOf[W]) threshold(level int) int {
 	if level <= 0 {
 		return s.K
 	}
@@ -433,7 +793,7 @@ func safeMul(a, b int) int {
 	return a * b
 }
 
-func labelInRange(label, low, high Label) bool {
+func labelInRange[W Weight](label, low, high LabelOf[W]) bool {
 	return !label.Less(low) && label.Less(high)
 }
 
@@ -455,15 +815,21 @@ func setToSlice(set map[int]struct{}) []int {
 	return result
 }
 
-func (s *Solver) reconstructPath(source, goal int) []int {
+func (s *SolverOf[W]) reconstructPath(source, goal int) []int {
+	return buildPath(s.Predecessors, source, goal)
+}
+
+func buildPath(prev []int, source, goal int) []int {
 	path := make([]int, 0, 16)
 	curr := goal
-	for curr != -1 {
+	// A path never has more vertices than the graph; stopping there keeps a
+	// malformed predecessor array from looping forever.
+	for curr != -1 && len(path) < len(prev) {
 		path = append(path, curr)
 		if curr == source {
 			break
 		}
-		curr = s.Predecessors[curr]
+		curr = prev[curr]
 	}
 	if len(path) == 0 || path[len(path)-1] != source {
 		return nil
@@ -474,29 +840,29 @@ func (s *Solver) reconstructPath(source, goal int) []int {
 	return path
 }
 
-type dijkstraItem struct {
+type dijkstraItem[W Weight] struct {
 	Vertex   int
-	Distance float64
+	Distance W
 	Index    int
 }
 
-type dijkstraQueue []*dijkstraItem
+type dijkstraQueue[W Weight] []*dijkstraItem[W]
 
-func (pq dijkstraQueue) Len() int { return len(pq) }
-func (pq dijkstraQueue) Less(i, j int) bool {
+func (pq dijkstraQueue[W]) Len() int { return len(pq) }
+func (pq dijkstraQueue[W]) Less(i, j int) bool {
 	return pq[i].Distance < pq[j].Distance
 }
-func (pq dijkstraQueue) Swap(i, j int) {
+func (pq dijkstraQueue[W]) Swap(i, j int) {
 	pq[i], pq[j] = pq[j], pq[i]
 	pq[i].Index = i
 	pq[j].Index = j
 }
-func (pq *dijkstraQueue) Push(x interface{}) {
-	item := x.(*dijkstraItem)
+func (pq *dijkstraQueue[W]) Push(x interface{}) {
+	item := x.(*dijkstraItem[W])
 	item.Index = len(*pq)
 	*pq = append(*pq, item)
 }
-func (pq *dijkstraQueue) Pop() interface{} {
+func (pq *dijkstraQueue[W]) Pop() interface{} {
 	old := *pq
 	n := len(old)
 	item := old[n-1]
@@ -506,22 +872,48 @@ func (pq *dijkstraQueue) Pop() interface{} {
 	return item
 }
 
-func Dijkstra(g *Graph, source, goal int) (float64, []int) {
+// Dijkstra returns the shortest distance and path from source to goal. It runs
+// on g.Adj directly, so unlike Solver it needs no Invalidate after Adj is
+// edited by hand.
+func Dijkstra[W Weight](g *GraphOf[W], source, goal int) (W, []int) {
+	dist, path, _ := DijkstraContext(context.Background(), g, source, goal)
+	return dist, path
+}
+
+// DijkstraCSR is Dijkstra on a compressed graph.
+func DijkstraCSR[W Weight](g *CSRGraphOf[W], source, goal int) (W, []int) {
+	dist, path, _ := DijkstraCSRContext(context.Background(), g, source, goal)
+	return dist, path
+}
+
+// DijkstraContext is like Dijkstra but gives up once ctx is done and returns
+// ctx.Err(). With integer weights, a goal whose distance does not fit W is
+// reported with ErrWeightOverflow.
+func DijkstraContext[W Weight](ctx context.Context, g *GraphOf[W], source, goal int) (W, []int, error) {
 	n := g.Vertices
-	dist := make([]float64, n)
+	inf := infinity[W]()
+	overflowed := false
+	dist := make([]W, n)
 	prev := make([]int, n)
 	for i := 0; i < n; i++ {
-		dist[i] = math.Inf(1)
+		dist[i] = inf
 		prev[i] = -1
 	}
 	dist[source] = 0
 
-	pq := &dijkstraQueue{}
+	pq := &dijkstraQueue[W]{}
 	heap.Init(pq)
-	heap.Push(pq, &dijkstraItem{Vertex: source, Distance: 0})
+	heap.Push(pq, &dijkstraItem[W]{Vertex: source, Distance: 0})
 
+	done := ctx.Done()
 	for pq.Len() > 0 {
-		item := heap.Pop(pq).(*dijkstraItem)
+		select {
+		case <-done:
+			return inf, nil, ctx.Err()
+		default:
+		}
+
+		item := heap.Pop(pq).(*dijkstraItem[W])
 		u := item.Vertex
 
 		if item.Distance > dist[u] {
@@ -534,30 +926,115 @@ func Dijkstra(g *Graph, source, goal int) (float64, []int) {
 
 		for _, edge := range g.Adj[u] {
 			v := edge.To
-			newDist := dist[u] + edge.Weight
+			newDist, ok := addWeights(dist[u], edge.Weight, inf)
+			if !ok {
+				overflowed = true
+				continue
+			}
 			if newDist < dist[v] {
 				dist[v] = newDist
 				prev[v] = u
-				heap.Push(pq, &dijkstraItem{Vertex: v, Distance: newDist})
+				heap.Push(pq, &dijkstraItem[W]{Vertex: v, Distance: newDist})
 			}
 		}
 	}
 
-	if math.IsInf(dist[goal], 1) {
-		return math.Inf(1), nil
+	if dist[goal] == inf {
+		if overflowed {
+			sources := []SourceOf[W]{{Vertex: source}}
+			err := checkOverflow(NewCSRGraph(g), sources, nil, func(v int) bool { return v == goal })
+			return inf, nil, err
+		}
+		return inf, nil, nil
 	}
+	return dist[goal], buildPath(prev, source, goal), nil
+}
 
-	path := make([]int, 0, 16)
-	curr := goal
-	for curr != -1 {
-		path = append(path, curr)
-		if curr == source {
+// DijkstraCSRContext is DijkstraContext on a compressed graph.
+func DijkstraCSRContext[W Weight](ctx context.Context, g *CSRGraphOf[W], source, goal int) (W, []int, error) {
+	inf := infinity[W]()
+	dist, prev, err := dijkstraSearch(ctx, g, []SourceOf[W]{{Vertex: source}}, goal, inf, nil)
+	if err != nil {
+		return inf, nil, err
+	}
+
+	if dist[goal] == inf {
+		return inf, nil, nil
+	}
+
+	return dist[goal], buildPath(prev, source, goal), nil
+}
+
+// dijkstraSearch runs Dijkstra from the given sources and returns the distance
+// and predecessor arrays. The search stops once goal is settled or the next
+// vertex is at distance bound or more; a negative goal settles every reachable
+// vertex. Only distances below bound are final. A non-nil allow restricts the
+// search to the edges it accepts. If ctx is done first, the search returns
+// ctx.Err(). Relaxations whose sums do not fit W are skipped; if that leaves
+// goal, or with a negative goal any vertex, reachable but without a distance,
+// an unbounded search returns ErrWeightOverflow.
+func dijkstraSearch[W Weight](ctx context.Context, g *CSRGraphOf[W], sources []SourceOf[W], goal int, bound W, allow EdgeFilter) ([]W, []int, error) {
+	n := g.Vertices
+	inf := infinity[W]()
+	overflowed := false
+	dist := make([]W, n)
+	prev := make([]int, n)
+	for i := 0; i < n; i++ {
+		dist[i] = inf
+		prev[i] = -1
+	}
+
+	pq := &dijkstraQueue[W]{}
+	heap.Init(pq)
+	for _, src := range sources {
+		if src.Offset < dist[src.Vertex] {
+			dist[src.Vertex] = src.Offset
+			heap.Push(pq, &dijkstraItem[W]{Vertex: src.Vertex, Distance: src.Offset})
+		}
+	}
+
+	done := ctx.Done()
+	for pq.Len() > 0 {
+		select {
+		case <-done:
+			return nil, nil, ctx.Err()
+		default:
+		}
+
+		item := heap.Pop(pq).(*dijkstraItem[W])
+		u := item.Vertex
+
+		if item.Distance > dist[u] {
+			continue
+		}
+
+		if item.Distance >= bound || u == goal {
 			break
 		}
-		curr = prev[curr]
+
+		for e := g.Offsets[u]; e < g.Offsets[u+1]; e++ {
+			v := g.Targets[e]
+			if allow != nil && !allow(u, v, e) {
+				continue
+			}
+			newDist, ok := addWeights(dist[u], g.Weights[e], inf)
+			if !ok {
+				overflowed = true
+				continue
+			}
+			if newDist < dist[v] {
+				dist[v] = newDist
+				prev[v] = u
+				heap.Push(pq, &dijkstraItem[W]{Vertex: v, Distance: newDist})
+			}
+		}
 	}
-	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
-		path[i], path[j] = path[j], path[i]
+
+	if overflowed && bound == inf && (goal < 0 || dist[goal] == inf) {
+		unreached := func(v int) bool { return dist[v] == inf && (goal < 0 || v == goal) }
+		if err := checkOverflow(g, sources, allow, unreached); err != nil {
+			return nil, nil, err
+		}
 	}
-	return dist[goal], path
+	return dist, prev, nil
 }
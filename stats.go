@@ -0,0 +1,162 @@
+package bmssp
+
+import (
+	"math/bits"
+	"time"
+)
+
+// Stats collects counters from the BMSSP recursion. Attach a Stats to
+// Solver.Stats to enable collection; a nil Stats costs nothing. Counters
+// accumulate across queries until Reset is called. Queries answered by the
+// Dijkstra fallback only increment DijkstraFallbacks.
+type Stats struct {
+	// MaxDepth is the deepest recursion level reached, counting the top-level
+	// call as depth 0.
+	MaxDepth int
+	// Calls counts BMSSP invocations, including base cases.
+	Calls int
+	// BaseCases counts base-case (bounded Dijkstra) invocations.
+	BaseCases int
+	// Pulls counts Frontier.Pull calls.
+	Pulls int
+	// BatchPrependSizes summarises the number of items of every BatchPrepend.
+	BatchPrependSizes Histogram
+	// FindPivotsCalls counts findPivots invocations.
+	FindPivotsCalls int
+	// PivotCounts summarises the number of pivots chosen by every findPivots
+	// call.
+	PivotCounts Histogram
+	// PivotEarlyExits counts findPivots calls whose working set outgrew k|S|,
+	// in which case every frontier vertex becomes a pivot.
+	PivotEarlyExits int
+	// Relaxations counts edge relaxations; Updates counts the successful ones,
+	// after which the target vertex is considered for the frontier.
+	Relaxations int
+	Updates     int
+	// DijkstraFallbacks counts queries answered by Dijkstra instead of BMSSP.
+	DijkstraFallbacks int
+	// Transformations counts builds of the constant-degree graph and
+	// TransformTime the total time spent in them.
+	Transformations int
+	TransformTime   time.Duration
+
+	// Trace, if set, is called when each BMSSP invocation returns.
+	Trace func(CallTrace)
+}
+
+// CallTrace describes one BMSSP invocation. Bound and ResultBound are distance
+// bounds; the call completed only part of its frontier when ResultBound is
+// below Bound.
+type CallTrace struct {
+	Level        int
+	Depth        int
+	Bound        float64
+	ResultBound  float64
+	FrontierSize int
+	ResultSize   int
+}
+
+// Histogram summarises a series of non-negative integers in constant space, so
+// that a long-lived Stats does not grow with the number of queries. Bucket 0
+// counts the zeros and bucket i > 0 the values in [2^(i-1), 2^i).
+type Histogram struct {
+	Count   int
+	Sum     int
+	Max     int
+	Buckets [65]int
+}
+
+// Mean returns the average of the recorded values, or 0 if there are none.
+func (h *Histogram) Mean() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return float64(h.Sum) / float64(h.Count)
+}
+
+func (h *Histogram) add(v int) {
+	h.Count++
+	h.Sum += v
+	if v > h.Max {
+		h.Max = v
+	}
+	h.Buckets[bits.Len(uint(v))]++
+}
+
+// Reset clears all counters. Trace is kept.
+func (st *Stats) Reset() {
+	*st = Stats{Trace: st.Trace}
+}
+
+// recordCall counts one BMSSP call in st. It is a function rather than a
+// method so that the bounds are converted to float64 only when a Trace wants
+// them.
+func recordCall[W Weight](st *Stats, level, depth int, bound, resultBound W, frontier, result int) {
+	if st == nil {
+		return
+	}
+	st.Calls++
+	if level <= 0 {
+		st.BaseCases++
+	}
+	if depth > st.MaxDepth {
+		st.MaxDepth = depth
+	}
+	if st.Trace != nil {
+		st.Trace(CallTrace{
+			Level:        level,
+			Depth:        depth,
+			Bound:        toFloat(bound),
+			ResultBound:  toFloat(resultBound),
+			FrontierSize: frontier,
+			ResultSize:   result,
+		})
+	}
+}
+
+func (st *Stats) pull() {
+	if st != nil {
+		st.Pulls++
+	}
+}
+
+func (st *Stats) batchPrepend(size int) {
+	if st != nil {
+		st.BatchPrependSizes.add(size)
+	}
+}
+
+func (st *Stats) pivots(count int, earlyExit bool) {
+	if st == nil {
+		return
+	}
+	st.FindPivotsCalls++
+	st.PivotCounts.add(count)
+	if earlyExit {
+		st.PivotEarlyExits++
+	}
+}
+
+func (st *Stats) relaxation(updated bool) {
+	if st == nil {
+		return
+	}
+	st.Relaxations++
+	if updated {
+		st.Updates++
+	}
+}
+
+func (st *Stats) fallback() {
+	if st != nil {
+		st.DijkstraFallbacks++
+	}
+}
+
+func (st *Stats) transformation(elapsed time.Duration) {
+	if st == nil {
+		return
+	}
+	st.Transformations++
+	st.TransformTime += elapsed
+}
@@ -0,0 +1,83 @@
+package bmssp
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	g := makeSparseGraph(300, 1200, 61)
+	stats := &Stats{}
+	traced := 0
+	stats.Trace = func(call CallTrace) {
+		traced++
+		if call.ResultBound > call.Bound {
+			t.Fatalf("result bound %f above bound %f", call.ResultBound, call.Bound)
+		}
+	}
+
+	solver := NewSolver(g)
+	solver.ForceBMSSP = true
+	solver.Stats = stats
+	solver.SolveAll(0)
+
+	if stats.Transformations != 1 || stats.TransformTime <= 0 {
+		t.Fatalf("expected one timed transformation, got %d in %v", stats.Transformations, stats.TransformTime)
+	}
+	if stats.Calls == 0 || stats.BaseCases == 0 || stats.Calls != traced {
+		t.Fatalf("unexpected call counts: calls=%d base=%d traced=%d", stats.Calls, stats.BaseCases, traced)
+	}
+	if stats.MaxDepth < 1 || stats.MaxDepth > solver.internal.Levels {
+		t.Fatalf("max depth %d outside [1, %d]", stats.MaxDepth, solver.internal.Levels)
+	}
+	if stats.Pulls == 0 || stats.FindPivotsCalls != stats.PivotCounts.Count {
+		t.Fatalf("unexpected pivot/pull counts: pulls=%d calls=%d counts=%d", stats.Pulls, stats.FindPivotsCalls, stats.PivotCounts.Count)
+	}
+	prepends := stats.BatchPrependSizes
+	bucketed := 0
+	for _, n := range prepends.Buckets {
+		bucketed += n
+	}
+	if prepends.Count == 0 || bucketed != prepends.Count || prepends.Mean() > float64(prepends.Max) {
+		t.Fatalf("inconsistent batch prepend histogram: %+v", prepends)
+	}
+	if stats.Relaxations == 0 || stats.Updates == 0 || stats.Updates > stats.Relaxations {
+		t.Fatalf("unexpected relaxation counts: %d relaxations, %d updates", stats.Relaxations, stats.Updates)
+	}
+
+	solver.SolveAll(1)
+	if stats.Transformations != 1 {
+		t.Fatalf("expected cached transformation to be reused, got %d builds", stats.Transformations)
+	}
+
+	stats.Reset()
+	if stats.Calls != 0 || stats.PivotCounts.Count != 0 || stats.Trace == nil {
+		t.Fatalf("expected Reset to clear counters and keep Trace")
+	}
+
+	solver.ForceBMSSP = false
+	solver.Solve(0, 5)
+	if stats.DijkstraFallbacks != 1 || stats.Calls != 0 {
+		t.Fatalf("expected a single fallback, got %d fallbacks and %d calls", stats.DijkstraFallbacks, stats.Calls)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	var h Histogram
+	for _, v := range []int{0, 1, 2, 3, 4, 1000} {
+		h.add(v)
+	}
+	if h.Count != 6 || h.Sum != 1010 || h.Max != 1000 {
+		t.Fatalf("unexpected totals: %+v", h)
+	}
+	// 0 | 1 | 2-3 | 4-7 | ... | 512-1023
+	want := map[int]int{0: 1, 1: 1, 2: 2, 3: 1, 10: 1}
+	for i, n := range h.Buckets {
+		if n != want[i] {
+			t.Fatalf("bucket %d holds %d values, want %d", i, n, want[i])
+		}
+	}
+	if mean := h.Mean(); mean < 168.3 || mean > 168.4 {
+		t.Fatalf("unexpected mean %f", mean)
+	}
+	if (&Histogram{}).Mean() != 0 {
+		t.Fatalf("expected mean 0 of an empty histogram")
+	}
+}
@@ -1,44 +1,102 @@
 package bmssp
 
-import "sort"
+import (
+	"context"
+	"sort"
+	"sync"
+)
 
-type Transformation struct {
-	Graph      *Graph
-	OrigToNew  []int
-	NewToOrig  []int
+// TransformationOf is the constant-degree graph of §2 together with the vertex
+// mappings between it and the original graph. CSR is the transformed graph in
+// compressed form, which is what the solver runs on; Graph holds the same graph
+// in adjacency-list form and is nil when the transformation was built from a
+// CSRGraph.
+type TransformationOf[W Weight] struct {
+	Graph     *GraphOf[W]
+	CSR       *CSRGraphOf[W]
+	OrigToNew []int
+	NewToOrig []int
+
+	// origins caches the result of edgeOrigins. It is held through a pointer
+	// so that transformations can be copied.
+	origins *transformOrigins
+}
+
+type transformOrigins struct {
+	once    sync.Once
+	origins *edgeOrigins
+}
+
+// Transformation is the transformation of a graph with float64 weights.
+type Transformation = TransformationOf[float64]
+
+// cancelCheckInterval is the number of vertices the transformation processes
+// between checks of its context.
+const cancelCheckInterval = 1 << 10
+
+func NewConstantDegreeGraph[W Weight](g *GraphOf[W]) *TransformationOf[W] {
+	t, _ := NewConstantDegreeGraphContext(context.Background(), g)
+	return t
 }
 
-func NewConstantDegreeGraph(g *Graph) *Transformation {
-	n := g.Vertices
+// NewConstantDegreeGraphContext is like NewConstantDegreeGraph but gives up
+// once ctx is done and returns ctx.Err().
+func NewConstantDegreeGraphContext[W Weight](ctx context.Context, g *GraphOf[W]) (*TransformationOf[W], error) {
+	t, err := NewConstantDegreeCSRContext(ctx, g.CSR())
+	if err != nil {
+		return nil, err
+	}
+	t.Graph = t.CSR.ToGraph()
+	return t, nil
+}
+
+// NewConstantDegreeCSR builds the constant-degree transformation of c without
+// materialising an adjacency-list copy of the result.
+func NewConstantDegreeCSR[W Weight](c *CSRGraphOf[W]) *TransformationOf[W] {
+	t, _ := NewConstantDegreeCSRContext(context.Background(), c)
+	return t
+}
+
+// NewConstantDegreeCSRContext is like NewConstantDegreeCSR but gives up once
+// ctx is done and returns ctx.Err().
+func NewConstantDegreeCSRContext[W Weight](ctx context.Context, c *CSRGraphOf[W]) (*TransformationOf[W], error) {
+	n := c.Vertices
 	if n == 0 {
-		return &Transformation{
-			Graph:     NewGraph(0),
+		return &TransformationOf[W]{
+			CSR:       NewCSRGraph(NewGraphOf[W](0)),
 			OrigToNew: nil,
 			NewToOrig: nil,
-		}
+		}, nil
 	}
 
-	weights := make([]map[int]float64, n)
+	weights := make([]map[int]W, n)
 	neighbors := make([]map[int]struct{}, n)
 	for i := 0; i < n; i++ {
 		neighbors[i] = make(map[int]struct{})
 	}
 
-	for u, edges := range g.Adj {
-		for _, edge := range edges {
+	for u := 0; u < n; u++ {
+		if err := checkContext(ctx, u); err != nil {
+			return nil, err
+		}
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			to, weight := c.Targets[e], c.Weights[e]
 			if weights[u] == nil {
-				weights[u] = make(map[int]float64)
+				weights[u] = make(map[int]W)
 			}
-			if prev, ok := weights[u][edge.To]; !ok || edge.Weight < prev {
-				weights[u][edge.To] = edge.Weight
+			if prev, ok := weights[u][to]; !ok || weight < prev {
+				weights[u][to] = weight
 			}
-			neighbors[u][edge.To] = struct{}{}
-			neighbors[edge.To][u] = struct{}{}
+			neighbors[u][to] = struct{}{}
+			neighbors[to][u] = struct{}{}
 		}
 	}
 
 	neighborList := make([][]int, n)
 	for v := 0; v < n; v++ {
+		if err := checkContext(ctx, v); err != nil {
+			return nil, err
+		}
 		if len(neighbors[v]) == 0 {
 			continue
 		}
@@ -74,7 +132,38 @@ func NewConstantDegreeGraph(g *Graph) *Transformation {
 		}
 	}
 
-	tg := NewGraph(next)
+	// Every transformed vertex has at most two outgoing edges: the next vertex
+	// of its cycle and the original edge it represents. Count them first so the
+	// result can be laid out directly in compressed form.
+	offsets := make([]int, next+1)
+	for v := 0; v < n; v++ {
+		list := neighborList[v]
+		if len(list) <= 1 {
+			continue
+		}
+		for _, w := range list {
+			offsets[indexMap[v][w]+1]++
+		}
+	}
+	for u := 0; u < n; u++ {
+		for v := range weights[u] {
+			offsets[indexMap[u][v]+1]++
+		}
+	}
+	for i := 0; i < next; i++ {
+		offsets[i+1] += offsets[i]
+	}
+
+	m := offsets[next]
+	targets := make([]int, m)
+	edgeWeights := make([]W, m)
+	cursor := append([]int(nil), offsets[:next]...)
+	addEdge := func(from, to int, w W) {
+		targets[cursor[from]] = to
+		edgeWeights[cursor[from]] = w
+		cursor[from]++
+	}
+
 	for v := 0; v < n; v++ {
 		list := neighborList[v]
 		if len(list) <= 1 {
@@ -83,11 +172,14 @@ func NewConstantDegreeGraph(g *Graph) *Transformation {
 		for i := 0; i < len(list); i++ {
 			from := indexMap[v][list[i]]
 			to := indexMap[v][list[(i+1)%len(list)]]
-			tg.AddEdge(from, to, 0)
+			addEdge(from, to, 0)
 		}
 	}
 
 	for u := 0; u < n; u++ {
+		if err := checkContext(ctx, u); err != nil {
+			return nil, err
+		}
 		if weights[u] == nil {
 			continue
 		}
@@ -100,32 +192,132 @@ func NewConstantDegreeGraph(g *Graph) *Transformation {
 			if !ok {
 				continue
 			}
-			tg.AddEdge(uIdx, vIdx, w)
+			addEdge(uIdx, vIdx, w)
 		}
 	}
 
-	return &Transformation{
-		Graph:     tg,
+	return &TransformationOf[W]{
+		CSR: &CSRGraphOf[W]{
+			Vertices: next,
+			Edges:    m,
+			Offsets:  offsets,
+			Targets:  targets,
+			Weights:  edgeWeights,
+		},
 		OrigToNew: origToNew,
 		NewToOrig: newToOrig,
+	}, nil
+}
+
+// checkContext returns ctx.Err() on every cancelCheckInterval-th iteration i.
+func checkContext(ctx context.Context, i int) error {
+	if i%cancelCheckInterval != 0 {
+		return nil
 	}
+	return ctx.Err()
 }
 
-func (t *Transformation) MapPath(path []int) []int {
+// MapPath maps a path of the transformed graph back to the original vertices.
+// Consecutive vertices of the same cycle collapse into one, and a detour that
+// leaves a vertex and returns to it over zero-weight edges is cut out, so the
+// result never visits a vertex twice.
+func (t *TransformationOf[W]) MapPath(path []int) []int {
 	if len(path) == 0 {
 		return nil
 	}
 	out := make([]int, 0, len(path))
-	last := -1
+	at := make(map[int]int, len(path))
 	for _, node := range path {
 		if node < 0 || node >= len(t.NewToOrig) {
 			continue
 		}
 		orig := t.NewToOrig[node]
-		if orig != last {
-			out = append(out, orig)
-			last = orig
+		if i, ok := at[orig]; ok {
+			for _, v := range out[i+1:] {
+				delete(at, v)
+			}
+			out = out[:i+1]
+			continue
 		}
+		at[orig] = len(out)
+		out = append(out, orig)
 	}
 	return out
 }
+
+// MapTree maps per-vertex distances and predecessors computed on the
+// transformed graph back to the original vertices. Every vertex of a cycle
+// shares the same distance, so the distance of an original vertex is read from
+// its representative. Its predecessor is taken from the cycle vertex closest to
+// the root of the transformed tree: the path to any other cycle vertex may
+// leave the cycle and re-enter it over zero-weight edges, which would turn into
+// a predecessor loop. Predecessors chosen this way always lie strictly closer
+// to the root, so the mapped tree is acyclic.
+func (t *TransformationOf[W]) MapTree(dist []W, pred []int) ([]W, []int) {
+	n := len(t.OrigToNew)
+	outDist := make([]W, n)
+	outPred := make([]int, n)
+	inf := infinity[W]()
+	depth := treeDepths(pred)
+	entry := make([]int, n)
+	for v := range entry {
+		entry[v] = -1
+	}
+	for node, v := range t.NewToOrig {
+		if dist[node] == inf || dist[node] != dist[t.OrigToNew[v]] || depth[node] < 0 {
+			continue
+		}
+		if entry[v] == -1 || depth[node] < depth[entry[v]] {
+			entry[v] = node
+		}
+	}
+	for v := 0; v < n; v++ {
+		outDist[v] = dist[t.OrigToNew[v]]
+		outPred[v] = -1
+		if entry[v] == -1 {
+			continue
+		}
+		p := pred[entry[v]]
+		for p != -1 && t.NewToOrig[p] == v {
+			p = pred[p]
+		}
+		if p != -1 {
+			outPred[v] = t.NewToOrig[p]
+		}
+	}
+	return outDist, outPred
+}
+
+// treeDepths returns the number of edges between every vertex and the root of
+// its tree in the predecessor forest pred. Vertices on or below a predecessor
+// cycle get -1.
+func treeDepths(pred []int) []int {
+	const unknown, visiting = -2, -3
+	depth := make([]int, len(pred))
+	for v := range depth {
+		depth[v] = unknown
+	}
+	var chain []int
+	for v := range pred {
+		chain = chain[:0]
+		u := v
+		for u != -1 && depth[u] == unknown {
+			depth[u] = visiting
+			chain = append(chain, u)
+			u = pred[u]
+		}
+		d := -1
+		if u != -1 {
+			d = depth[u]
+		}
+		for i := len(chain) - 1; i >= 0; i-- {
+			if u != -1 && d < 0 {
+				depth[chain[i]] = -1
+				continue
+			}
+			d++
+			depth[chain[i]] = d
+		}
+	}
+	return depth
+}
@@ -0,0 +1,37 @@
+package bmssp
+
+// ShortestPathTreeOf holds the result of a single-source computation: the
+// distance from Source to every vertex and the predecessor of each vertex on
+// its shortest path. Unreachable vertices have an infinite distance and a
+// predecessor of -1.
+type ShortestPathTreeOf[W Weight] struct {
+	Source       int
+	Distances    []W
+	Predecessors []int
+
+	// repair is the index Repair keeps between calls. It is held through a
+	// pointer so that trees can be copied.
+	repair *repairIndex[W]
+}
+
+// ShortestPathTree is the tree of a graph with float64 weights.
+type ShortestPathTree = ShortestPathTreeOf[float64]
+
+// DistanceTo returns the shortest distance from the source to v, or infinity
+// (+Inf for floating-point weights) if v is unreachable or not a vertex of the
+// graph.
+func (t *ShortestPathTreeOf[W]) DistanceTo(v int) W {
+	if v < 0 || v >= len(t.Distances) {
+		return infinity[W]()
+	}
+	return t.Distances[v]
+}
+
+// PathTo returns the shortest path from the source to v, including both
+// endpoints, or nil if v is unreachable.
+func (t *ShortestPathTreeOf[W]) PathTo(v int) []int {
+	if t.DistanceTo(v) == infinity[W]() {
+		return nil
+	}
+	return buildPath(t.Predecessors, t.Source, v)
+}
@@ -0,0 +1,101 @@
+package bmssp
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSolveAll(t *testing.T) {
+	rng := rand.New(rand.NewSource(21))
+	for iter := 0; iter < 15; iter++ {
+		n := 12 + rng.Intn(12)
+		g := makeSparseGraph(n, n*(n-1)/5, rng.Int63())
+
+		source := rng.Intn(n)
+		for _, force := range []bool{false, true} {
+			solver := NewSolver(g)
+			solver.ForceBMSSP = force
+			tree := solver.SolveAll(source)
+			if tree == nil {
+				t.Fatalf("expected tree for source %d", source)
+			}
+			assertTreeMatchesDijkstra(t, g, tree)
+		}
+	}
+}
+
+func TestSolveAll_InvalidSource(t *testing.T) {
+	g := NewGraph(3)
+	g.AddEdge(0, 1, 1)
+
+	solver := NewSolver(g)
+	if tree := solver.SolveAll(5); tree != nil {
+		t.Fatalf("expected nil tree for invalid source, got %+v", tree)
+	}
+}
+
+func assertTreeMatchesDijkstra(t *testing.T, g *Graph, tree *ShortestPathTree) {
+	t.Helper()
+	for v := 0; v < g.Vertices; v++ {
+		want, _ := Dijkstra(g, tree.Source, v)
+		got := tree.DistanceTo(v)
+		if math.IsInf(want, 1) {
+			if !math.IsInf(got, 1) || tree.PathTo(v) != nil {
+				t.Fatalf("expected vertex %d unreachable, got dist=%f path=%v", v, got, tree.PathTo(v))
+			}
+			continue
+		}
+		if math.Abs(got-want) > 1e-9 {
+			t.Fatalf("distance mismatch at %d: tree=%f dijkstra=%f", v, got, want)
+		}
+		assertValidPath(t, g, tree.Source, v, got, tree.PathTo(v))
+	}
+}
+
+// TestSolveAll_ZeroWeightCycle covers a high-degree vertex on a zero-weight
+// cycle. In the transformed graph the cheapest way around the hub's own cycle
+// leaves it and re-enters through the cycle's other vertices, which must not
+// turn into a predecessor loop in the original tree.
+func TestSolveAll_ZeroWeightCycle(t *testing.T) {
+	const hub = 50
+	g := NewGraph(hub + 1)
+	g.AddEdge(40, hub, 1)
+	for v := 1; v < hub; v++ {
+		if v == 40 || v == 41 || v == 49 {
+			continue
+		}
+		g.AddEdge(hub, v, 1)
+	}
+	g.AddEdge(hub, 41, 0)
+	g.AddEdge(41, 49, 0)
+	g.AddEdge(49, hub, 0)
+
+	for _, force := range []bool{false, true} {
+		solver := NewSolver(g)
+		solver.ForceBMSSP = force
+		tree := solver.SolveAll(40)
+		if tree == nil {
+			t.Fatal("expected tree")
+		}
+		for v := range tree.Predecessors {
+			seen := make(map[int]bool)
+			for p := v; p != -1; p = tree.Predecessors[p] {
+				if seen[p] {
+					t.Fatalf("predecessor loop through %d: %v", p, tree.Predecessors)
+				}
+				seen[p] = true
+			}
+		}
+		assertTreeMatchesDijkstra(t, g, tree)
+
+		_, path := solver.Solve(40, hub)
+		seen := make(map[int]bool)
+		for _, v := range path {
+			if seen[v] {
+				t.Fatalf("path visits %d twice: %v", v, path)
+			}
+			seen[v] = true
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package bmssp
+
+import (
+	"fmt"
+	"math"
+	"unsafe"
+)
+
+// Weight is the set of edge weight types. Integer weights compare and add
+// exactly, so BMSSP and Dijkstra return identical distances; floating-point
+// weights may round differently depending on the order in which a path is
+// summed.
+type Weight interface {
+	~int32 | ~int64 | ~uint32 | ~float32 | ~float64
+}
+
+// isFloat reports whether W is a floating-point type.
+func isFloat[W Weight]() bool {
+	var one W = 1
+	return one/2 != 0
+}
+
+// infinity returns the distance of an unreachable vertex: +Inf for
+// floating-point weights and the largest value of W for integer weights. An
+// integer distance can therefore never reach that value; sums that would are
+// overflows.
+func infinity[W Weight]() W {
+	if isFloat[W]() {
+		return W(math.Inf(1))
+	}
+	var zero W
+	bits := 8 * unsafe.Sizeof(zero)
+	if zero-1 < zero {
+		return W(uint64(1)<<(bits-1) - 1)
+	}
+	return W(uint64(1)<<bits - 1)
+}
+
+// addWeights returns a+b and whether the sum is exact. Floating-point sums are
+// always accepted; an integer sum fails if it wraps around or reaches
+// infinity. Searches treat a failed sum as no improvement, so a vertex whose
+// distance does not fit W keeps an infinite distance; checkOverflow tells such
+// a vertex apart from an unreachable one.
+func addWeights[W Weight](a, b W, inf W) (W, bool) {
+	sum := a + b
+	if inf == inf+1 {
+		// Only +Inf absorbs an increment, so W is a floating-point type.
+		return sum, true
+	}
+	if (sum < a) != (b < 0) || sum == inf {
+		return sum, false
+	}
+	return sum, true
+}
+
+// checkOverflow returns ErrWeightOverflow if a vertex for which unreached
+// reports true can be reached from sources over the edges allow accepts. It is
+// called after a search skipped a relaxation whose sum did not fit W, and
+// unreached selects the vertices whose infinite distance the search would
+// return: those vertices are reachable only over paths too long for W. A nil
+// allow accepts every edge.
+func checkOverflow[W Weight](c *CSRGraphOf[W], sources []SourceOf[W], allow EdgeFilter, unreached func(v int) bool) error {
+	inf := infinity[W]()
+	seen := make([]bool, c.Vertices)
+	queue := make([]int, 0, len(sources))
+	for _, src := range sources {
+		if src.Offset != inf && !seen[src.Vertex] {
+			seen[src.Vertex] = true
+			queue = append(queue, src.Vertex)
+		}
+	}
+	for i := 0; i < len(queue); i++ {
+		u := queue[i]
+		if unreached(u) {
+			return fmt.Errorf("%w: distance to vertex %d", ErrWeightOverflow, u)
+		}
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			v := c.Targets[e]
+			if seen[v] || allow != nil && !allow(u, v, e) {
+				continue
+			}
+			seen[v] = true
+			queue = append(queue, v)
+		}
+	}
+	return nil
+}
+
+// isNaN reports whether w is a floating-point NaN.
+func isNaN[W Weight](w W) bool {
+	return w != w
+}
+
+// toFloat converts a distance to float64, mapping infinity to +Inf.
+func toFloat[W Weight](w W) float64 {
+	if w == infinity[W]() {
+		return math.Inf(1)
+	}
+	return float64(w)
+}
@@ -0,0 +1,275 @@
+package bmssp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// makeWeightedGraph builds a random graph with weights in [1, maxWeight] and a
+// few parallel edges of equal weight, which produce many exact ties.
+func makeWeightedGraph[W Weight](n, m int, maxWeight int, seed int64) *GraphOf[W] {
+	g := NewGraphOf[W](n)
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < m; i++ {
+		u := rng.Intn(n)
+		v := rng.Intn(n - 1)
+		if v >= u {
+			v++
+		}
+		w := W(1 + rng.Intn(maxWeight))
+		g.AddEdge(u, v, w)
+		if i%10 == 0 {
+			g.AddEdge(u, v, w)
+		}
+	}
+	return g
+}
+
+func testGenericSolver[W Weight](t *testing.T, name string) {
+	g := makeWeightedGraph[W](300, 1200, 5, 251)
+	rng := rand.New(rand.NewSource(253))
+	solvers := []*SolverOf[W]{
+		NewSolverWithOptions(g, Options{Algorithm: AlgorithmBMSSP}),
+		NewSolverWithOptions(g, Options{Algorithm: AlgorithmBMSSP, DisableTransformation: true}),
+		NewSolverWithOptions(g, Options{Algorithm: AlgorithmDijkstra, BidirectionalFallback: true}),
+	}
+	for i := 0; i < 30; i++ {
+		source, goal := rng.Intn(g.Vertices), rng.Intn(g.Vertices)
+		want, _ := Dijkstra(g, source, goal)
+		for _, s := range solvers {
+			got, path, err := s.TrySolve(source, goal)
+			if err != nil {
+				t.Fatalf("%s %v: unexpected error: %v", name, s.Options, err)
+			}
+			if got != want {
+				t.Fatalf("%s %v: distance mismatch %d->%d: %v vs %v", name, s.Options, source, goal, got, want)
+			}
+			if got == infinity[W]() {
+				if path != nil {
+					t.Fatalf("%s: expected no path %d->%d, got %v", name, source, goal, path)
+				}
+				continue
+			}
+			var total W
+			for j := 0; j+1 < len(path); j++ {
+				best := infinity[W]()
+				for _, edge := range g.Adj[path[j]] {
+					if edge.To == path[j+1] && edge.Weight < best {
+						best = edge.Weight
+					}
+				}
+				if best == infinity[W]() {
+					t.Fatalf("%s: path %v uses a missing edge", name, path)
+				}
+				total += best
+			}
+			if path[0] != source || path[len(path)-1] != goal || total != want {
+				t.Fatalf("%s: invalid path %v of length %v for %d->%d at %v", name, path, total, source, goal, want)
+			}
+		}
+	}
+
+	tree := solvers[0].SolveAll(0)
+	reference := solvers[2].SolveAll(0)
+	for v := range tree.Distances {
+		if tree.DistanceTo(v) != reference.DistanceTo(v) {
+			t.Fatalf("%s: tree distance to %d is %v, want %v", name, v, tree.DistanceTo(v), reference.DistanceTo(v))
+		}
+	}
+}
+
+func TestGenericWeights(t *testing.T) {
+	testGenericSolver[int32](t, "int32")
+	testGenericSolver[int64](t, "int64")
+	testGenericSolver[uint32](t, "uint32")
+	testGenericSolver[float32](t, "float32")
+	testGenericSolver[float64](t, "float64")
+}
+
+func TestInfinity(t *testing.T) {
+	if got := infinity[int32](); got != math.MaxInt32 {
+		t.Fatalf("int32: got %d", got)
+	}
+	if got := infinity[int64](); got != math.MaxInt64 {
+		t.Fatalf("int64: got %d", got)
+	}
+	if got := infinity[uint32](); got != math.MaxUint32 {
+		t.Fatalf("uint32: got %d", got)
+	}
+	if got := infinity[float32](); !math.IsInf(float64(got), 1) {
+		t.Fatalf("float32: got %v", got)
+	}
+	if got := infinity[float64](); !math.IsInf(got, 1) {
+		t.Fatalf("float64: got %v", got)
+	}
+
+	type millis int64
+	if got := infinity[millis](); got != math.MaxInt64 {
+		t.Fatalf("named int64: got %d", got)
+	}
+}
+
+func TestAddWeights(t *testing.T) {
+	tests := []struct {
+		a, b int32
+		ok   bool
+	}{
+		{1, 2, true},
+		{math.MaxInt32 - 2, 1, true},
+		{math.MaxInt32 - 1, 1, false},
+		{math.MaxInt32, 1, false},
+		{math.MaxInt32 - 1, math.MaxInt32 - 1, false},
+		{5, -3, true},
+	}
+	for _, tt := range tests {
+		if _, ok := addWeights(tt.a, tt.b, infinity[int32]()); ok != tt.ok {
+			t.Fatalf("%d+%d: got ok=%v, want %v", tt.a, tt.b, ok, tt.ok)
+		}
+	}
+	if _, ok := addWeights(uint32(math.MaxUint32-1), 1, infinity[uint32]()); ok {
+		t.Fatalf("uint32 sum reaching infinity was accepted")
+	}
+	if _, ok := addWeights(uint32(math.MaxUint32-1), 5, infinity[uint32]()); ok {
+		t.Fatalf("wrapping uint32 sum was accepted")
+	}
+	if sum, ok := addWeights(math.MaxFloat64, math.MaxFloat64, math.Inf(1)); !ok || !math.IsInf(sum, 1) {
+		t.Fatalf("float64 sums must not be rejected, got %v %v", sum, ok)
+	}
+}
+
+func TestWeightOverflow(t *testing.T) {
+	// A chain of heavy edges whose total does not fit an int32.
+	n := 8
+	g := NewGraphOf[int32](n)
+	for v := 0; v+1 < n; v++ {
+		g.AddEdge(v, v+1, math.MaxInt32/4)
+	}
+	// Padding, so that BMSSP is not bypassed by a trivial graph.
+	for v := 0; v < n; v++ {
+		g.AddEdge(v, (v+3)%n, math.MaxInt32/2)
+	}
+
+	if _, _, err := DijkstraContext(context.Background(), g, 0, n-1); !errors.Is(err, ErrWeightOverflow) {
+		t.Fatalf("Dijkstra: expected ErrWeightOverflow, got %v", err)
+	}
+	if d, path, err := DijkstraContext(context.Background(), g, 0, 2); err != nil || d != 2*(math.MaxInt32/4) || len(path) != 3 {
+		t.Fatalf("Dijkstra: unexpected result %d %v %v", d, path, err)
+	}
+	for _, opts := range []Options{
+		{Algorithm: AlgorithmBMSSP},
+		{Algorithm: AlgorithmBMSSP, DisableTransformation: true},
+		{Algorithm: AlgorithmDijkstra},
+		{Algorithm: AlgorithmDijkstra, BidirectionalFallback: true},
+	} {
+		s := NewSolverWithOptions(g, opts)
+		if _, _, err := s.TrySolve(0, n-1); !errors.Is(err, ErrWeightOverflow) {
+			t.Fatalf("%v: expected ErrWeightOverflow, got %v", opts, err)
+		}
+		if d, path := s.Solve(0, n-1); d != infinity[int32]() || path != nil {
+			t.Fatalf("%v: expected no result after overflow, got %d %v", opts, d, path)
+		}
+		if _, err := s.TrySolveAll(0); !errors.Is(err, ErrWeightOverflow) {
+			t.Fatalf("%v: SolveAll: expected ErrWeightOverflow, got %v", opts, err)
+		}
+	}
+}
+
+func TestWeightOverflow_OffShortestPaths(t *testing.T) {
+	// Vertex heavy sits just below the int32 limit, so every edge out of it
+	// overflows. Its targets are all reachable over short paths, so no query
+	// fails until an edge to lost, reachable only from heavy, is added.
+	base := makeWeightedGraph[int32](200, 800, 5, 257)
+	n := base.Vertices + 2
+	heavy, lost := n-2, n-1
+	g := NewGraphOf[int32](n)
+	for u, edges := range base.Adj {
+		for _, edge := range edges {
+			g.AddEdge(u, edge.To, edge.Weight)
+		}
+	}
+	g.AddEdge(0, heavy, math.MaxInt32-3)
+	for v := 1; v < base.Vertices; v += 7 {
+		g.AddEdge(heavy, v, 5)
+	}
+
+	options := []Options{
+		{Algorithm: AlgorithmBMSSP},
+		{Algorithm: AlgorithmBMSSP, DisableTransformation: true},
+		{Algorithm: AlgorithmDijkstra},
+		{Algorithm: AlgorithmDijkstra, BidirectionalFallback: true},
+	}
+	for _, opts := range options {
+		s := NewSolverWithOptions(g, opts)
+		tree, err := s.TrySolveAll(0)
+		if err != nil {
+			t.Fatalf("%v: SolveAll failed: %v", opts, err)
+		}
+		for v := 0; v < base.Vertices; v++ {
+			want, _ := Dijkstra(base, 0, v)
+			got, _, err := s.TrySolve(0, v)
+			if err != nil || got != want || tree.DistanceTo(v) != want {
+				t.Fatalf("%v: distance to %d is %d/%d (%v), want %d", opts, v, got, tree.DistanceTo(v), err, want)
+			}
+		}
+		if d, _, err := s.TrySolve(0, heavy); err != nil || d != math.MaxInt32-3 {
+			t.Fatalf("%v: distance to heavy vertex is %d (%v)", opts, d, err)
+		}
+		if d, _, err := s.TrySolve(0, lost); err != nil || d != infinity[int32]() {
+			t.Fatalf("%v: expected lost vertex to be unreachable, got %d (%v)", opts, d, err)
+		}
+	}
+
+	tree, err := NewSolverWithOptions(g, Options{Algorithm: AlgorithmDijkstra}).TrySolveAll(0)
+	if err != nil {
+		t.Fatalf("SolveAll failed: %v", err)
+	}
+	g.AddEdge(heavy, lost, 5)
+	if err := tree.Repair(g, heavy, lost); !errors.Is(err, ErrWeightOverflow) {
+		t.Fatalf("Repair: expected ErrWeightOverflow, got %v", err)
+	}
+	for _, opts := range options {
+		s := NewSolverWithOptions(g, opts)
+		if _, _, err := s.TrySolve(0, lost); !errors.Is(err, ErrWeightOverflow) {
+			t.Fatalf("%v: expected ErrWeightOverflow for lost vertex, got %v", opts, err)
+		}
+		if _, err := s.TrySolveAll(0); !errors.Is(err, ErrWeightOverflow) {
+			t.Fatalf("%v: SolveAll: expected ErrWeightOverflow, got %v", opts, err)
+		}
+		if d, _, err := s.TrySolve(0, 1); err != nil || d == infinity[int32]() {
+			t.Fatalf("%v: unexpected result %d %v", opts, d, err)
+		}
+	}
+	if _, _, err := DijkstraContext(context.Background(), g, 0, lost); !errors.Is(err, ErrWeightOverflow) {
+		t.Fatalf("Dijkstra: expected ErrWeightOverflow, got %v", err)
+	}
+
+	// Without the edge, lost is unreachable again and the repaired tree
+	// matches a fresh solve.
+	g.RemoveEdge(heavy, lost)
+	if err := tree.Repair(g, heavy, lost); err != nil {
+		t.Fatalf("Repair: unexpected error %v", err)
+	}
+	fresh := NewSolverWithOptions(g, Options{Algorithm: AlgorithmDijkstra}).SolveAll(0)
+	for v := range fresh.Distances {
+		if tree.Distances[v] != fresh.Distances[v] {
+			t.Fatalf("repaired distance to %d is %d, want %d", v, tree.Distances[v], fresh.Distances[v])
+		}
+	}
+}
+
+func TestGenericGraphValidation(t *testing.T) {
+	g := NewGraphOf[int64](2)
+	if err := g.TryAddEdge(0, 1, -1); !errors.Is(err, ErrNegativeWeight) {
+		t.Fatalf("expected ErrNegativeWeight, got %v", err)
+	}
+	f := NewGraphOf[float32](2)
+	if err := f.TryAddEdge(0, 1, float32(math.NaN())); !errors.Is(err, ErrNaNWeight) {
+		t.Fatalf("expected ErrNaNWeight, got %v", err)
+	}
+	if _, err := TryNewGraphOf[uint32](-1); !errors.Is(err, ErrNegativeVertexCount) {
+		t.Fatalf("expected ErrNegativeVertexCount, got %v", err)
+	}
+}
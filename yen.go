@@ -0,0 +1,214 @@
+package bmssp
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// Path is a path through a graph, given as its vertices from start to end,
+// together with its total weight.
+type Path struct {
+	Cost     float64
+	Vertices []int
+}
+
+// pathLess orders paths like Label: by cost, then by number of edges, then
+// lexicographically by vertex ids.
+func pathLess(a, b Path) bool {
+	if a.Cost != b.Cost {
+		return a.Cost < b.Cost
+	}
+	if len(a.Vertices) != len(b.Vertices) {
+		return len(a.Vertices) < len(b.Vertices)
+	}
+	for i := range a.Vertices {
+		if a.Vertices[i] != b.Vertices[i] {
+			return a.Vertices[i] < b.Vertices[i]
+		}
+	}
+	return false
+}
+
+// KShortestPaths returns up to k shortest loopless paths from source to goal
+// using Yen's algorithm, in increasing order of cost with ties broken like
+// Label. Parallel edges count once, at their minimum weight. It returns nil if
+// goal is unreachable or either vertex is not in the graph.
+func KShortestPaths(g *Graph, source, goal, k int) []Path {
+	paths, _ := KShortestPathsContext(context.Background(), g, source, goal, k)
+	return paths
+}
+
+// KShortestPathsContext is like KShortestPaths but reports an invalid vertex
+// with ErrVertexOutOfRange and gives up once ctx is done, returning ctx.Err().
+func KShortestPathsContext(ctx context.Context, g *Graph, source, goal, k int) ([]Path, error) {
+	c := g.CSR()
+	if err := checkVertex(source, c.Vertices); err != nil {
+		return nil, err
+	}
+	if err := checkVertex(goal, c.Vertices); err != nil {
+		return nil, err
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+
+	// Spur searches run on one solver over the shared graph and skip removed
+	// vertices and edges through a filter instead of editing a copy.
+	solver := NewSolver(g)
+	rev := c.Reverse()
+	blocked := make([]bool, c.Vertices)
+	blockedNext := make(map[int]bool)
+	spur := -1
+	usable := func(u, v int) bool {
+		return !blocked[v] && !(u == spur && blockedNext[v])
+	}
+	allow := func(u, v, e int) bool { return usable(u, v) }
+	everything := func(u, v int) bool { return true }
+	shortest := func(from int, filter EdgeFilter, usable func(u, v int) bool) ([]int, error) {
+		dist, _, err := solver.search(ctx, []Source{{Vertex: from}}, solver.inf, filter)
+		if err != nil || math.IsInf(dist[goal], 1) {
+			return nil, err
+		}
+		return smallestPath(c, rev, dist, from, goal, usable), nil
+	}
+
+	first, err := shortest(source, nil, everything)
+	if first == nil {
+		return nil, err
+	}
+	accepted := []Path{{Cost: pathCost(c, first), Vertices: first}}
+	var candidates []Path
+	seen := map[string]bool{pathKey(first): true}
+
+	for len(accepted) < k {
+		last := accepted[len(accepted)-1].Vertices
+		for i := 0; i+1 < len(last); i++ {
+			spur = last[i]
+			root := last[:i+1]
+
+			// Remove the next edge of every accepted path that shares this
+			// root, and the root itself apart from the spur vertex.
+			for v := range blockedNext {
+				delete(blockedNext, v)
+			}
+			for _, p := range accepted {
+				if len(p.Vertices) > i+1 && equalInts(p.Vertices[:i+1], root) {
+					blockedNext[p.Vertices[i+1]] = true
+				}
+			}
+			for _, v := range root[:i] {
+				blocked[v] = true
+			}
+			tail, err := shortest(spur, allow, usable)
+			for _, v := range root[:i] {
+				blocked[v] = false
+			}
+			if err != nil {
+				return nil, err
+			}
+			if tail == nil {
+				continue
+			}
+
+			vertices := append(append([]int(nil), root[:i]...), tail...)
+			if key := pathKey(vertices); !seen[key] {
+				seen[key] = true
+				candidates = append(candidates, Path{Cost: pathCost(c, vertices), Vertices: vertices})
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+
+		sort.Slice(candidates, func(a, b int) bool { return pathLess(candidates[a], candidates[b]) })
+		accepted = append(accepted, candidates[0])
+		candidates = candidates[1:]
+	}
+	return accepted, nil
+}
+
+// smallestPath returns the pathLess-smallest of the shortest paths from source
+// to goal that only use edges usable accepts, given the distances dist from
+// source. Every such path consists of tight edges, on which the distance grows
+// by exactly the edge weight. A breadth-first search back from goal over the
+// tight edges finds the fewest edges each vertex needs, and the path then
+// follows the smallest next vertex that keeps that count minimal.
+func smallestPath(c, rev *CSRGraph, dist []float64, source, goal int, usable func(u, v int) bool) []int {
+	tight := func(u, v int, w float64) bool {
+		return !math.IsInf(dist[u], 1) && dist[u]+w == dist[v] && usable(u, v)
+	}
+	hops := make([]int, c.Vertices)
+	for v := range hops {
+		hops[v] = -1
+	}
+	hops[goal] = 0
+	queue := []int{goal}
+	for len(queue) > 0 && hops[source] == -1 {
+		v := queue[0]
+		queue = queue[1:]
+		for e := rev.Offsets[v]; e < rev.Offsets[v+1]; e++ {
+			u := rev.Targets[e]
+			if hops[u] == -1 && tight(u, v, rev.Weights[e]) {
+				hops[u] = hops[v] + 1
+				queue = append(queue, u)
+			}
+		}
+	}
+	if hops[source] == -1 {
+		return nil
+	}
+
+	path := []int{source}
+	for u := source; u != goal; {
+		next := -1
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			v := c.Targets[e]
+			if hops[v] == hops[u]-1 && (next == -1 || v < next) && tight(u, v, c.Weights[e]) {
+				next = v
+			}
+		}
+		path = append(path, next)
+		u = next
+	}
+	return path
+}
+
+// pathCost sums the weights along path, using the lightest of parallel edges.
+// Summing from the start keeps costs of paths with a common prefix comparable
+// exactly.
+func pathCost(c *CSRGraph, path []int) float64 {
+	cost := 0.0
+	for i := 0; i+1 < len(path); i++ {
+		u, v := path[i], path[i+1]
+		w := math.Inf(1)
+		for e := c.Offsets[u]; e < c.Offsets[u+1]; e++ {
+			if c.Targets[e] == v && c.Weights[e] < w {
+				w = c.Weights[e]
+			}
+		}
+		cost += w
+	}
+	return cost
+}
+
+func pathKey(path []int) string {
+	b := make([]byte, 0, binary.MaxVarintLen64*len(path))
+	for _, v := range path {
+		b = binary.AppendUvarint(b, uint64(v))
+	}
+	return string(b)
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
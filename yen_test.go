@@ -0,0 +1,132 @@
+package bmssp
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// allSimplePaths enumerates every loopless path from source to goal.
+func allSimplePaths(g *Graph, source, goal int) []Path {
+	c := g.CSR()
+	var paths []Path
+	onPath := make([]bool, g.Vertices)
+	var walk func(path []int)
+	walk = func(path []int) {
+		u := path[len(path)-1]
+		if u == goal {
+			vertices := append([]int(nil), path...)
+			paths = append(paths, Path{Cost: pathCost(c, vertices), Vertices: vertices})
+			return
+		}
+		visited := make(map[int]bool)
+		for _, e := range g.Adj[u] {
+			if onPath[e.To] || visited[e.To] {
+				continue
+			}
+			visited[e.To] = true
+			onPath[e.To] = true
+			walk(append(path, e.To))
+			onPath[e.To] = false
+		}
+	}
+	onPath[source] = true
+	walk([]int{source})
+	sort.Slice(paths, func(i, j int) bool { return pathLess(paths[i], paths[j]) })
+	return paths
+}
+
+func TestKShortestPaths(t *testing.T) {
+	rng := rand.New(rand.NewSource(191))
+	for iter := 0; iter < 30; iter++ {
+		n := 5 + rng.Intn(6)
+		g := makeSparseGraph(n, 2*n+rng.Intn(n), rng.Int63())
+		source, goal := rng.Intn(n), rng.Intn(n)
+		want := allSimplePaths(g, source, goal)
+		got := KShortestPaths(g, source, goal, 8)
+		if len(want) > 8 {
+			want = want[:8]
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%d->%d: expected %d paths, got %d", source, goal, len(want), len(got))
+		}
+		for i := range got {
+			if math.Abs(got[i].Cost-want[i].Cost) > 1e-9 {
+				t.Fatalf("%d->%d path %d: cost %f, want %f", source, goal, i, got[i].Cost, want[i].Cost)
+			}
+			assertValidPath(t, g, source, goal, got[i].Cost, got[i].Vertices)
+			seen := make(map[int]bool)
+			for _, v := range got[i].Vertices {
+				if seen[v] {
+					t.Fatalf("path %v is not loopless", got[i].Vertices)
+				}
+				seen[v] = true
+			}
+			if i > 0 && pathLess(got[i], got[i-1]) {
+				t.Fatalf("paths out of order: %v before %v", got[i-1], got[i])
+			}
+		}
+	}
+}
+
+func TestKShortestPaths_Ties(t *testing.T) {
+	// Two paths of cost 2 and one of cost 3 through a grid of unit edges.
+	g := NewGraph(4)
+	g.AddEdge(0, 2, 1)
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(1, 3, 1)
+	g.AddEdge(2, 3, 1)
+	g.AddEdge(0, 3, 3)
+	got := KShortestPaths(g, 0, 3, 5)
+	want := [][]int{{0, 1, 3}, {0, 2, 3}, {0, 3}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d paths, got %v", len(want), got)
+	}
+	for i := range want {
+		if !equalInts(got[i].Vertices, want[i]) {
+			t.Fatalf("path %d: got %v, want %v", i, got[i].Vertices, want[i])
+		}
+	}
+}
+
+func TestKShortestPaths_EqualCostOrder(t *testing.T) {
+	// 0->2->3->5 ties with 0->1->4->5 and is found first when the edges out of
+	// 0 are added in this order; pathLess puts 0->1->4->5 first.
+	g := NewGraph(6)
+	g.AddEdge(0, 2, 1)
+	g.AddEdge(0, 1, 1)
+	g.AddEdge(2, 3, 1)
+	g.AddEdge(1, 4, 1)
+	g.AddEdge(3, 5, 1)
+	g.AddEdge(4, 5, 1)
+	got := KShortestPaths(g, 0, 5, 1)
+	if len(got) != 1 || !equalInts(got[0].Vertices, []int{0, 1, 4, 5}) {
+		t.Fatalf("expected [0 1 4 5], got %v", got)
+	}
+
+	// With small integer weights most paths tie; the result must match the
+	// pathLess order of every simple path exactly.
+	rng := rand.New(rand.NewSource(193))
+	for iter := 0; iter < 30; iter++ {
+		n := 5 + rng.Intn(5)
+		g := NewGraph(n)
+		for i := 0; i < 3*n; i++ {
+			g.AddEdge(rng.Intn(n), rng.Intn(n), float64(1+rng.Intn(2)))
+		}
+		source, goal := rng.Intn(n), rng.Intn(n)
+		want := allSimplePaths(g, source, goal)
+		if len(want) > 6 {
+			want = want[:6]
+		}
+		got := KShortestPaths(g, source, goal, 6)
+		if len(got) != len(want) {
+			t.Fatalf("%d->%d: expected %d paths, got %d", source, goal, len(want), len(got))
+		}
+		for i := range want {
+			if !equalInts(got[i].Vertices, want[i].Vertices) {
+				t.Fatalf("%d->%d path %d: got %v, want %v", source, goal, i, got[i].Vertices, want[i].Vertices)
+			}
+		}
+	}
+}